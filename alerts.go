@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// alertMetrics are the values a rule can be set on, computed for every
+// GC cycle from the cycles seen so far.
+var alertMetrics = map[string]func(a *Alerter, t *gctrace) float64{
+	// stop-the-world pause, in milliseconds
+	"pause": func(a *Alerter, t *gctrace) float64 { return pauseMs(t) },
+	// share of CPU spent in GC since the program started, in percent
+	"cpu": func(a *Alerter, t *gctrace) float64 { return float64(t.CPUPercent) },
+	// heap goal, in megabytes
+	"goal": func(a *Alerter, t *gctrace) float64 { return float64(t.Heap1) },
+	// GC cycles during the last second
+	"rate": func(a *Alerter, t *gctrace) float64 { return float64(len(a.recent)) },
+}
+
+// An AlertRule is breached when its metric goes above its limit.
+type AlertRule struct {
+	Metric string
+	Limit  float64
+}
+
+func (r AlertRule) String() string {
+	return fmt.Sprintf("%s>%s", r.Metric, formatFloat(r.Limit))
+}
+
+// ParseAlertRule parses a rule written as metric>limit, such as
+// pause>10 or cpu>25.
+func ParseAlertRule(s string) (AlertRule, error) {
+	fields := strings.SplitN(s, ">", 2)
+	if len(fields) != 2 {
+		return AlertRule{}, fmt.Errorf("alert rule %q: expected metric>limit", s)
+	}
+
+	metric := strings.TrimSpace(fields[0])
+	if _, ok := alertMetrics[metric]; !ok {
+		return AlertRule{}, fmt.Errorf("alert rule %q: unknown metric %q, use pause, cpu, goal or rate", s, metric)
+	}
+
+	limit, err := strconv.ParseFloat(strings.TrimSpace(fields[1]), 64)
+	if err != nil {
+		return AlertRule{}, fmt.Errorf("alert rule %q: %v", s, err)
+	}
+
+	return AlertRule{Metric: metric, Limit: limit}, nil
+}
+
+// alertRules implements flag.Value so that -alert can be repeated.
+type alertRules []AlertRule
+
+func (r *alertRules) String() string {
+	var rules []string
+	for _, rule := range *r {
+		rules = append(rules, rule.String())
+	}
+	return strings.Join(rules, ",")
+}
+
+func (r *alertRules) Set(s string) error {
+	rule, err := ParseAlertRule(s)
+	if err != nil {
+		return err
+	}
+	*r = append(*r, rule)
+	return nil
+}
+
+// A Breach records a rule going above its limit.
+type Breach struct {
+	Rule        string
+	ElapsedTime float64
+	Value       float64
+}
+
+func (b Breach) String() string {
+	return fmt.Sprintf("alert %s breached at %.3fs: %s", b.Rule, b.ElapsedTime, formatFloat(b.Value))
+}
+
+// An Alerter evaluates rules against every GC cycle after the warm-up. A
+// breach is reported when a rule goes above its limit, not again until
+// it has dropped back below it.
+type Alerter struct {
+	rules    []AlertRule
+	breached []bool
+	recent   []float64 // times of the cycles during the last second
+	cycles   int
+
+	Warmup   Warmup
+	Breaches []Breach
+}
+
+func NewAlerter(rules []AlertRule) *Alerter {
+	return &Alerter{
+		rules:    rules,
+		breached: make([]bool, len(rules)),
+	}
+}
+
+func (a *Alerter) Check(t *gctrace) []Breach {
+	a.recent = append(a.recent, t.ElapsedTime)
+	for len(a.recent) > 0 && a.recent[0] <= t.ElapsedTime-1 {
+		a.recent = a.recent[1:]
+	}
+
+	a.cycles++
+	if !a.Warmup.Over(a.cycles, t.ElapsedTime) {
+		return nil
+	}
+
+	var breaches []Breach
+	for i, rule := range a.rules {
+		value := alertMetrics[rule.Metric](a, t)
+		if value <= rule.Limit {
+			a.breached[i] = false
+			continue
+		}
+		if a.breached[i] {
+			continue
+		}
+		a.breached[i] = true
+		breaches = append(breaches, Breach{
+			Rule:        rule.String(),
+			ElapsedTime: t.ElapsedTime,
+			Value:       value,
+		})
+	}
+
+	a.Breaches = append(a.Breaches, breaches...)
+	return breaches
+}
+
+// Reset forgets every cycle and breach seen so far.
+func (a *Alerter) Reset() {
+	a.breached = make([]bool, len(a.rules))
+	a.recent = nil
+	a.cycles = 0
+	a.Breaches = nil
+}
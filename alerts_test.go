@@ -0,0 +1,45 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAlertRule(t *testing.T) {
+	rule, err := ParseAlertRule("pause>10.5")
+	if err != nil {
+		t.Fatalf("ParseAlertRule returned an error: %v", err)
+	}
+	if rule != (AlertRule{Metric: "pause", Limit: 10.5}) {
+		t.Errorf("Unexpected rule: %+v", rule)
+	}
+
+	for _, invalid := range []string{"pause", "heap>1", "cpu>lots"} {
+		if _, err := ParseAlertRule(invalid); err == nil {
+			t.Errorf("Expected ParseAlertRule(%q) to return an error.", invalid)
+		}
+	}
+}
+
+func TestAlerterReportsEachBreachOnce(t *testing.T) {
+	alerter := NewAlerter([]AlertRule{{Metric: "pause", Limit: 1}, {Metric: "rate", Limit: 2}})
+
+	traces := []*gctrace{
+		{ElapsedTime: 1.0, STWSclock: 2},
+		{ElapsedTime: 1.1, STWSclock: 3},
+		{ElapsedTime: 1.2, STWSclock: 0.5},
+		{ElapsedTime: 3.0, STWSclock: 4},
+	}
+	for _, trace := range traces {
+		alerter.Check(trace)
+	}
+
+	expected := []Breach{
+		{Rule: "pause>1", ElapsedTime: 1.0, Value: 2},
+		{Rule: "rate>2", ElapsedTime: 1.2, Value: 3},
+		{Rule: "pause>1", ElapsedTime: 3.0, Value: 4},
+	}
+	if !reflect.DeepEqual(alerter.Breaches, expected) {
+		t.Errorf("Expected breaches to equal %+v. Got %+v instead.", expected, alerter.Breaches)
+	}
+}
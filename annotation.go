@@ -0,0 +1,31 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// An Annotation marks a moment of the session, such as the start of a
+// load test phase, on every chart.
+type Annotation struct {
+	ElapsedTime float64
+	Label       string
+}
+
+// NewAnnotation returns an annotation with its label kept to one line.
+func NewAnnotation(elapsed float64, label string) Annotation {
+	return Annotation{
+		ElapsedTime: elapsed,
+		Label:       strings.Join(strings.Fields(label), " "),
+	}
+}
+
+func (g *Graph) AddAnnotation(a Annotation) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.Annotations = append(g.Annotations, a)
+	sort.SliceStable(g.Annotations, func(i, j int) bool {
+		return g.Annotations[i].ElapsedTime < g.Annotations[j].ElapsedTime
+	})
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewAnnotation(t *testing.T) {
+	a := NewAnnotation(1.5, "  ramp\nstart\t ")
+
+	expected := Annotation{ElapsedTime: 1.5, Label: "ramp start"}
+	if a != expected {
+		t.Errorf("Expected annotation to equal %v. Got %v instead.", expected, a)
+	}
+}
+
+func TestGraphAddAnnotation(t *testing.T) {
+	graph := NewGraph("fake title", GCVIS_TMPL)
+	graph.AddAnnotation(NewAnnotation(3, "second"))
+	graph.AddAnnotation(NewAnnotation(1, "first"))
+	graph.AddAnnotation(NewAnnotation(3, "third"))
+
+	expected := []Annotation{{1, "first"}, {3, "second"}, {3, "third"}}
+	if !reflect.DeepEqual(graph.Annotations, expected) {
+		t.Errorf("Expected annotations to equal %v. Got %v instead.", expected, graph.Annotations)
+	}
+
+	graph.Reset()
+	if len(graph.Annotations) != 0 {
+		t.Errorf("Expected Reset to drop annotations. Got %v instead.", graph.Annotations)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log"
+	"path"
+	"strings"
+)
+
+//go:generate go run assets_gen.go
+
+//go:embed static
+var staticFiles embed.FS
+
+// assetFiles holds the bundled scripts under static/.
+var assetFiles fs.FS = staticFiles
+
+type frontendAsset struct {
+	Name string
+	URL  string
+}
+
+// frontendAssets lists the scripts the page loads, from static/assets.txt.
+var frontendAssets = readAssetList()
+
+func readAssetList() []frontendAsset {
+	list, err := staticFiles.ReadFile("static/assets.txt")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var assets []frontendAsset
+	sc := bufio.NewScanner(bytes.NewReader(list))
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		assets = append(assets, frontendAsset{Name: fields[0], URL: fields[1]})
+	}
+
+	return assets
+}
+
+// assetSource returns the bundled content of a script, if it was fetched
+// into static/ before building.
+func assetSource(name string) ([]byte, bool) {
+	src, err := fs.ReadFile(assetFiles, path.Join("static", name))
+	return src, err == nil
+}
+
+// A pageScript is loaded either from Src or, when inlined, from its
+// source in Inline.
+type pageScript struct {
+	Src    string
+	Inline template.JS
+}
+
+// liveScripts are the script tags of the page served by HttpServer,
+// which serves the bundled scripts itself under /static/.
+func liveScripts() []pageScript {
+	var scripts []pageScript
+	for _, asset := range frontendAssets {
+		if _, ok := assetSource(asset.Name); ok {
+			scripts = append(scripts, pageScript{Src: "/static/" + asset.Name})
+		} else {
+			scripts = append(scripts, pageScript{Src: asset.URL})
+		}
+	}
+	return scripts
+}
+
+// missingAssets lists the scripts that were not fetched into static/
+// before building.
+func missingAssets() []string {
+	var missing []string
+	for _, asset := range frontendAssets {
+		if _, ok := assetSource(asset.Name); !ok {
+			missing = append(missing, asset.Name)
+		}
+	}
+	return missing
+}
+
+// inlineScripts carries the source of every bundled script, for pages
+// that must work without a server. It fails if any script was not
+// bundled, rather than leave the page needing the network.
+func inlineScripts() ([]pageScript, error) {
+	var scripts []pageScript
+	for _, asset := range frontendAssets {
+		src, ok := assetSource(asset.Name)
+		if !ok {
+			return nil, fmt.Errorf("%s is not bundled into this gcvis; run go generate before building it", asset.Name)
+		}
+		scripts = append(scripts, pageScript{Inline: template.JS(src)})
+	}
+	return scripts, nil
+}
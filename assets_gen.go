@@ -0,0 +1,84 @@
+//go:build ignore
+
+// assets_gen fetches the frontend scripts listed in static/assets.txt
+// into static/, so that they are bundled into the gcvis binary. Each
+// script must match the sha256 pinned for it in the list, or it is not
+// written. A script with no sha256 yet has the one it was fetched with
+// pinned in the list, to be reviewed along with the script.
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const listFile = "static/assets.txt"
+
+func main() {
+	list, err := os.ReadFile(listFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	lines := strings.SplitAfter(string(list), "\n")
+	pinned := false
+	for i, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		sum := ""
+		if len(fields) > 2 {
+			sum = fields[2]
+		}
+		got, err := fetch(filepath.Join("static", fields[0]), fields[1], sum)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if sum == "" {
+			log.Printf("%s: pinned sha256 %s", fields[0], got)
+			lines[i] = fmt.Sprintf("%s %s %s\n", fields[0], fields[1], got)
+			pinned = true
+		}
+	}
+
+	if pinned {
+		if err := os.WriteFile(listFile, []byte(strings.Join(lines, "")), 0644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// fetch writes the script at url to filename, if its sha256 is sum or no
+// sum is given, and returns its sha256.
+func fetch(filename, url, sum string) (string, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s: %s", url, resp.Status)
+	}
+
+	src, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	digest := sha256.Sum256(src)
+	got := hex.EncodeToString(digest[:])
+	if sum != "" && !strings.EqualFold(sum, got) {
+		return "", fmt.Errorf("%s: sha256 is %s, expected %s", url, got, sum)
+	}
+
+	return got, os.WriteFile(filename, src, 0644)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+)
+
+// bundleStubScripts stands in for the bundled scripts, which a tree
+// that has not run go generate lacks, and returns a func to restore
+// them.
+func bundleStubScripts() func() {
+	stubs := fstest.MapFS{}
+	for _, asset := range frontendAssets {
+		stubs["static/"+asset.Name] = &fstest.MapFile{Data: []byte("/* " + asset.Name + " */")}
+	}
+
+	saved := assetFiles
+	assetFiles = stubs
+	return func() { assetFiles = saved }
+}
+
+func TestInlineScripts(t *testing.T) {
+	defer bundleStubScripts()()
+
+	scripts, err := inlineScripts()
+	if err != nil {
+		t.Fatalf("inlineScripts returned an error: %v", err)
+	}
+	if len(scripts) != len(frontendAssets) || scripts[0].Src != "" || scripts[0].Inline == "" {
+		t.Errorf("Expected every script to be inlined. Got %+v.", scripts)
+	}
+}
+
+func TestWriteHTMLReportUnbundled(t *testing.T) {
+	saved := assetFiles
+	assetFiles = fstest.MapFS{}
+	defer func() { assetFiles = saved }()
+
+	graph := NewGraph("fake title", GCVIS_TMPL)
+	if err := graph.WriteHTMLReport(&bytes.Buffer{}); err == nil {
+		t.Errorf("Expected an error for a report without its scripts.")
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// A Tolerance is how far a statistic may rise above its baseline, either
+// by an absolute amount or by a percentage of the baseline.
+type Tolerance struct {
+	Value   float64
+	Percent bool
+}
+
+// ParseTolerance parses a tolerance written as an amount, such as 0.5,
+// or as a percentage, such as 10%.
+func ParseTolerance(s string) (Tolerance, error) {
+	t := Tolerance{Percent: strings.HasSuffix(s, "%")}
+
+	var err error
+	t.Value, err = strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+	if err != nil || t.Value < 0 {
+		return Tolerance{}, fmt.Errorf("tolerance %q: expected an amount or a percentage", s)
+	}
+	return t, nil
+}
+
+func (t Tolerance) String() string {
+	if t.Percent {
+		return formatFloat(t.Value) + "%"
+	}
+	return formatFloat(t.Value)
+}
+
+// Limit is the highest value allowed for a statistic whose baseline is
+// base.
+func (t Tolerance) Limit(base float64) float64 {
+	if t.Percent {
+		return base + base*t.Value/100
+	}
+	return base + t.Value
+}
+
+func (t Tolerance) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *Tolerance) UnmarshalText(text []byte) error {
+	var err error
+	*t, err = ParseTolerance(string(text))
+	return err
+}
+
+// defaultTolerances are saved along with a new baseline, to be edited to
+// suit the program.
+var defaultTolerances = map[string]Tolerance{
+	"cycles": {Value: 10, Percent: true},
+	"p50":    {Value: 20, Percent: true},
+	"p95":    {Value: 20, Percent: true},
+	"p99":    {Value: 20, Percent: true},
+	"goal":   {Value: 10, Percent: true},
+	"cpu":    {Value: 1},
+}
+
+// A Baseline holds the statistics of a reference run, keyed as in
+// reportStats, and how far a later run may rise above each of them.
+// Statistics without a tolerance are shown but not checked.
+type Baseline struct {
+	Title      string
+	Stats      map[string]float64
+	Tolerances map[string]Tolerance
+}
+
+func NewBaseline(r Report) Baseline {
+	b := Baseline{
+		Title:      r.Title,
+		Stats:      map[string]float64{},
+		Tolerances: map[string]Tolerance{},
+	}
+	for _, s := range reportStats {
+		b.Stats[s.Key] = s.Value(r)
+	}
+	for key, t := range defaultTolerances {
+		b.Tolerances[key] = t
+	}
+	return b
+}
+
+func ReadBaseline(r io.Reader) (Baseline, error) {
+	var b Baseline
+	if err := json.NewDecoder(r).Decode(&b); err != nil {
+		return Baseline{}, err
+	}
+	for key := range b.Tolerances {
+		if !isReportStat(key) {
+			return Baseline{}, fmt.Errorf("tolerance for unknown statistic %q", key)
+		}
+	}
+	return b, nil
+}
+
+func (b Baseline) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(b)
+}
+
+func isReportStat(key string) bool {
+	for _, s := range reportStats {
+		if s.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// A baselineCheck compares one statistic of a run with its baseline.
+type baselineCheck struct {
+	statDelta
+	Tolerance *Tolerance
+}
+
+func (c baselineCheck) Failed() bool {
+	return c.Tolerance != nil && c.After > c.Tolerance.Limit(c.Before)
+}
+
+// Check compares a run with the baseline. Tolerances in overrides take
+// the place of those saved in the baseline.
+func (b Baseline) Check(r Report, overrides map[string]Tolerance) []baselineCheck {
+	var checks []baselineCheck
+	for _, s := range reportStats {
+		base, ok := b.Stats[s.Key]
+		if !ok {
+			continue
+		}
+
+		c := baselineCheck{statDelta: statDelta{
+			Name:   s.Name,
+			Unit:   s.Unit,
+			Before: base,
+			After:  s.Value(r),
+		}}
+		if t, ok := overrides[s.Key]; ok {
+			c.Tolerance = &t
+		} else if t, ok := b.Tolerances[s.Key]; ok {
+			c.Tolerance = &t
+		}
+		checks = append(checks, c)
+	}
+	return checks
+}
+
+// writeBaselineChecks prints every check with its result and returns how
+// many of them failed.
+func writeBaselineChecks(w io.Writer, title string, checks []baselineCheck) (int, error) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+
+	failed := 0
+	fmt.Fprintf(tw, "baseline\t%s\t\t\t\t\n", title)
+	fmt.Fprintf(tw, "\tbaseline\tcurrent\tchange\tlimit\tresult\n")
+	for _, c := range checks {
+		limit, result := "", "-"
+		if c.Tolerance != nil {
+			limit = fmt.Sprintf("<= %.4g%s (%s)", c.Tolerance.Limit(c.Before), c.Unit, c.Tolerance)
+			result = "ok"
+			if c.Failed() {
+				result = "FAIL"
+				failed++
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%.4g%s\t%.4g%s\t%s\t%s\t%s\n",
+			c.Name, c.Before, c.Unit, c.After, c.Unit, c.Change(), limit, result)
+	}
+
+	return failed, tw.Flush()
+}
+
+// tolerances implements flag.Value so that -tolerance can be repeated.
+type tolerances map[string]Tolerance
+
+func (t tolerances) String() string {
+	var s []string
+	for key, tolerance := range t {
+		s = append(s, key+"="+tolerance.String())
+	}
+	return strings.Join(s, ",")
+}
+
+func (t tolerances) Set(s string) error {
+	fields := strings.SplitN(s, "=", 2)
+	if len(fields) != 2 {
+		return fmt.Errorf("tolerance %q: expected statistic=tolerance", s)
+	}
+	if !isReportStat(fields[0]) {
+		return fmt.Errorf("tolerance %q: unknown statistic %q", s, fields[0])
+	}
+
+	tolerance, err := ParseTolerance(fields[1])
+	if err != nil {
+		return err
+	}
+	t[fields[0]] = tolerance
+	return nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParseTolerance(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected Tolerance
+		limit    float64
+	}{
+		{"10%", Tolerance{Value: 10, Percent: true}, 110},
+		{"0.5", Tolerance{Value: 0.5}, 100.5},
+	}
+
+	for _, c := range cases {
+		tolerance, err := ParseTolerance(c.input)
+		if err != nil {
+			t.Fatalf("ParseTolerance(%q) returned an error: %v", c.input, err)
+		}
+		if tolerance != c.expected {
+			t.Errorf("Expected tolerance to equal %+v. Got %+v instead.", c.expected, tolerance)
+		}
+		if limit := tolerance.Limit(100); limit != c.limit {
+			t.Errorf("Expected limit of %v to equal %v. Got %v instead.", c.input, c.limit, limit)
+		}
+	}
+
+	for _, input := range []string{"", "ten", "-5%"} {
+		if _, err := ParseTolerance(input); err == nil {
+			t.Errorf("Expected ParseTolerance(%q) to fail.", input)
+		}
+	}
+}
+
+func TestBaselineCheck(t *testing.T) {
+	graph := NewGraph("before", GCVIS_TMPL)
+	graph.AddGCTraceGraphPoint(&gctrace{ElapsedTime: 1, Heap1: 100, STWSclock: 1, Nproc: 1})
+	graph.AddGCTraceGraphPoint(&gctrace{ElapsedTime: 2, Heap1: 100, STWSclock: 1, Nproc: 1})
+
+	w := &bytes.Buffer{}
+	if err := NewBaseline(graph.Report()).WriteJSON(w); err != nil {
+		t.Fatalf("WriteJSON returned an error: %v", err)
+	}
+	baseline, err := ReadBaseline(w)
+	if err != nil {
+		t.Fatalf("ReadBaseline returned an error: %v", err)
+	}
+
+	graph.AddGCTraceGraphPoint(&gctrace{ElapsedTime: 3, Heap1: 105, STWSclock: 2, Nproc: 1})
+	checks := baseline.Check(graph.Report(), tolerances{"goal": {Value: 1}})
+
+	failed := map[string]bool{}
+	for _, c := range checks {
+		failed[c.Name] = c.Failed()
+	}
+	for name, expected := range map[string]bool{"cycles": true, "peak heap goal": true, "pause p50": false, "wall time": false} {
+		if failed[name] != expected {
+			t.Errorf("Expected %s to fail: %v. Got %v instead.", name, expected, failed[name])
+		}
+	}
+
+	w.Reset()
+	n, err := writeBaselineChecks(w, baseline.Title, checks)
+	if err != nil {
+		t.Fatalf("writeBaselineChecks returned an error: %v", err)
+	}
+	if n == 0 || !strings.Contains(w.String(), "FAIL") {
+		t.Errorf("Expected failed checks to be reported. Got %d:\n%v", n, w.String())
+	}
+}
+
+func TestReadBaselineUnknownStatistic(t *testing.T) {
+	_, err := ReadBaseline(strings.NewReader(`{"Stats": {}, "Tolerances": {"p42": "10%"}}`))
+	if err == nil {
+		t.Errorf("Expected a tolerance for an unknown statistic to fail.")
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// A benchmark's name is printed when it starts, and its result on
+	// the same line once it is done. Anything the benchmark prints, GC
+	// traces included, lands between the two.
+	benchStartRe  = regexp.MustCompile(`^(Benchmark\S+)(?:\s+|$)`)
+	benchResultRe = regexp.MustCompile(`^\s*\d+\s+[\d.]+ ns/op`)
+	benchEndRe    = regexp.MustCompile(`^(--- (FAIL|SKIP|BENCH)|PASS$|FAIL|ok\s)`)
+)
+
+// trackBenchmark follows the benchmarks run by go test -bench, and
+// returns what is left of line to parse, if anything. The start of every
+// benchmark is annotated. A benchmark's name is held back while it runs,
+// and put in front of its result again, so that the result line is
+// passed on whole whatever was printed in between.
+func (p *Parser) trackBenchmark(line string, elapsed float64) (string, bool) {
+	if m := benchStartRe.FindStringSubmatchIndex(line); m != nil {
+		p.flushBenchmarkName()
+		name := line[m[2]:m[3]]
+		if name != p.benchmark {
+			p.benchmark = name
+			p.AnnotationChan <- NewAnnotation(p.place(elapsed), name)
+		}
+
+		rest := line[m[1]:]
+		if benchResultRe.MatchString(rest) {
+			p.benchmark = ""
+			return line, true
+		}
+
+		// output of the benchmark, if any, followed its name
+		p.benchName = line[:m[1]]
+		return rest, rest != ""
+	}
+
+	if benchResultRe.MatchString(line) {
+		p.benchmark = ""
+		line, p.benchName = p.benchName+line, ""
+		return line, true
+	}
+	if benchEndRe.MatchString(line) {
+		p.benchmark = ""
+		p.flushBenchmarkName()
+	}
+	return line, true
+}
+
+// flushBenchmarkName passes on the name of a benchmark that ended without
+// a result, as one that failed does.
+func (p *Parser) flushBenchmarkName() {
+	if p.benchName != "" {
+		p.NoMatchChan <- strings.TrimSpace(p.benchName)
+		p.benchName = ""
+	}
+}
+
+// BenchmarkStats describes the GC cycles that happened while one
+// benchmark ran.
+type BenchmarkStats struct {
+	Name string
+	CycleStats
+}
+
+// benchmarkStats groups traces by benchmark, in the order the benchmarks
+// ran. Benchmarks that saw no GC cycle are left out.
+func benchmarkStats(traces []*gctrace) []BenchmarkStats {
+	var stats []BenchmarkStats
+	index := map[string]int{}
+	for _, t := range traces {
+		if t.Benchmark == "" {
+			continue
+		}
+		i, ok := index[t.Benchmark]
+		if !ok {
+			i = len(stats)
+			index[t.Benchmark] = i
+			stats = append(stats, BenchmarkStats{Name: t.Benchmark})
+		}
+
+		stats[i].add(t)
+	}
+	return stats
+}
+
+func (g *Graph) Benchmarks() []BenchmarkStats {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	traces, _ := g.measured()
+	return benchmarkStats(traces)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParserBenchmarks(t *testing.T) {
+	input := strings.Join([]string{
+		"goos: linux",
+		"BenchmarkAlloc-8   \t" + gcLine(1, 0.1, 0.25, 4, 5, 2, 5),
+		gcLine(2, 0.2, 0.25, 5, 9, 3, 9),
+		"    1000\t   1234 ns/op",
+		"BenchmarkQuiet-8   \t 5000000\t    12.5 ns/op",
+		"BenchmarkGrow-8    \t" + gcLine(3, 0.4, 0.5, 9, 12, 6, 12),
+		"     200\t  99999 ns/op",
+		"PASS",
+		gcLine(4, 0.5, 0.1, 4, 5, 2, 5),
+	}, "\n")
+
+	parser := NewParser(bytes.NewReader([]byte(input)))
+	parser.Benchmarks = true
+	output := &bytes.Buffer{}
+	graph, err := collectSession("go test -bench .", parser, output)
+	if err != nil {
+		t.Fatalf("collectSession returned an error: %v", err)
+	}
+
+	expectedOutput := strings.Join([]string{
+		"goos: linux",
+		"BenchmarkAlloc-8   \t    1000\t   1234 ns/op",
+		"BenchmarkQuiet-8   \t 5000000\t    12.5 ns/op",
+		"BenchmarkGrow-8    \t     200\t  99999 ns/op",
+		"PASS",
+	}, "\n") + "\n"
+	if output.String() != expectedOutput {
+		t.Errorf("Expected the benchmark output to be passed on whole. Got:\n%v", output.String())
+	}
+
+	expected := []BenchmarkStats{
+		{Name: "BenchmarkAlloc-8", CycleStats: CycleStats{Cycles: 2, PauseTotal: 1, PeakGoal: 9, PeakLive: 3}},
+		{Name: "BenchmarkGrow-8", CycleStats: CycleStats{Cycles: 1, PauseTotal: 1, PeakGoal: 12, PeakLive: 6}},
+	}
+	if benchmarks := graph.Benchmarks(); !reflect.DeepEqual(benchmarks, expected) {
+		t.Errorf("Expected benchmarks to equal %+v. Got %+v instead.", expected, benchmarks)
+	}
+
+	labels := annotationLabels(graph)
+	if expected := []string{"BenchmarkAlloc-8", "BenchmarkQuiet-8", "BenchmarkGrow-8"}; !reflect.DeepEqual(labels, expected) {
+		t.Errorf("Expected every benchmark start to be annotated as %v. Got %v instead.", expected, labels)
+	}
+
+	w := &bytes.Buffer{}
+	if err := graph.Report().WriteTable(w); err != nil {
+		t.Fatalf("WriteTable returned an error: %v", err)
+	}
+	if !strings.Contains(w.String(), "BenchmarkGrow-8") {
+		t.Errorf("Expected the report to list benchmarks. Got:\n%v", w.String())
+	}
+}
@@ -0,0 +1,456 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+type chartSeries struct {
+	Name  string // as in graph.json
+	Label string
+}
+
+type chartDef struct {
+	Name    string
+	Unit    string
+	Stacked bool
+	Series  []chartSeries
+	Right   *chartAxis
+}
+
+// A chartAxis is a second y axis, on the right of a chart, for series
+// that don't share the unit of the others.
+type chartAxis struct {
+	Unit   string
+	Series []chartSeries
+}
+
+// chartDefs mirror the charts of the page.
+var chartDefs = []chartDef{
+	{"heap", "MB", false, []chartSeries{
+		{"HeapUse", "gc.heapinuse"},
+		{"ScvgInuse", "scvg.inuse"},
+		{"ScvgIdle", "scvg.idle"},
+		{"ScvgSys", "scvg.sys"},
+		{"ScvgReleased", "scvg.released"},
+		{"ScvgConsumed", "scvg.consumed"},
+	}, nil},
+	{"clock", "ms", true, []chartSeries{
+		{"STWSclock", "STW sweep clock"},
+		{"MASclock", "con mas clock"},
+		{"STWMclock", "STW mark clock"},
+	}, nil},
+	{"cpu", "ms", true, []chartSeries{
+		{"STWScpu", "STW sweep cpu"},
+		{"MASAssistcpu", "con mas assist cpu"},
+		{"MASBGcpu", "con mas bg cpu"},
+		{"MASIdlecpu", "con mas idle cpu"},
+		{"STWMcpu", "STW mark cpu"},
+	}, nil},
+	{"rate", "MB/s", false, []chartSeries{
+		{"AllocRate", "alloc rate"},
+	}, &chartAxis{"%", []chartSeries{
+		{"GCCPUPercent", "gc cpu"},
+	}}},
+}
+
+func findChartDef(name string) (chartDef, bool) {
+	for _, def := range chartDefs {
+		if def.Name == name {
+			return def, true
+		}
+	}
+	return chartDef{}, false
+}
+
+// the default series colours of the page
+var chartColors = []color.RGBA{
+	{0xed, 0xc2, 0x40, 0xff},
+	{0xaf, 0xd8, 0xf8, 0xff},
+	{0xcb, 0x4b, 0x4b, 0xff},
+	{0x4d, 0xa7, 0x4d, 0xff},
+	{0x94, 0x40, 0xed, 0xff},
+	{0xbd, 0x9b, 0x33, 0xff},
+}
+
+type chartOptions struct {
+	Width, Height int
+	From, To      float64 // x range in seconds; To <= From means no upper bound
+	Series        []string
+}
+
+// parseChartOptions reads chart options from a query string, as in
+// width=800&height=300&from=10&to=60&series=HeapUse,ScvgSys.
+func parseChartOptions(q url.Values) (chartOptions, error) {
+	opts := chartOptions{Width: 1200, Height: 340}
+
+	ints := []struct {
+		name string
+		dst  *int
+	}{{"width", &opts.Width}, {"height", &opts.Height}}
+	for _, o := range ints {
+		if v := q.Get(o.name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 100 || n > 4000 {
+				return opts, fmt.Errorf("invalid %s %q", o.name, v)
+			}
+			*o.dst = n
+		}
+	}
+
+	floats := []struct {
+		name string
+		dst  *float64
+	}{{"from", &opts.From}, {"to", &opts.To}}
+	for _, o := range floats {
+		if v := q.Get(o.name); v != "" {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return opts, fmt.Errorf("invalid %s %q", o.name, v)
+			}
+			*o.dst = f
+		}
+	}
+
+	if v := q.Get("series"); v != "" {
+		opts.Series = strings.Split(v, ",")
+	}
+
+	return opts, nil
+}
+
+func (o chartOptions) wants(series string) bool {
+	if len(o.Series) == 0 {
+		return true
+	}
+	for _, s := range o.Series {
+		if s == series {
+			return true
+		}
+	}
+	return false
+}
+
+func (o chartOptions) inRange(x float64) bool {
+	return x >= o.From && (o.To <= o.From || x <= o.To)
+}
+
+type plotLine struct {
+	Label  string
+	Color  color.RGBA
+	Points [][2]float64 // in pixels
+}
+
+type plotTick struct {
+	Pos   float64 // in pixels
+	Label string
+}
+
+// A plot is a chart laid out in pixels, ready to be drawn as SVG or PNG.
+type plot struct {
+	Width, Height            int
+	Title                    string
+	Left, Top, Right, Bottom float64 // the plotting area
+	Lines                    []plotLine
+	XTicks, YTicks           []plotTick
+	RightTicks               []plotTick // of the right y axis, if any
+}
+
+// niceStep picks a round tick step giving about n ticks over span.
+func niceStep(span float64, n int) float64 {
+	if span <= 0 {
+		return 1
+	}
+	raw := span / float64(n)
+	mag := math.Pow(10, math.Floor(math.Log10(raw)))
+	for _, m := range []float64{1, 2, 5, 10} {
+		if raw <= m*mag {
+			return m * mag
+		}
+	}
+	return 10 * mag
+}
+
+// niceMax rounds the top of a y axis up to a whole tick, and returns it
+// with the tick step.
+func niceMax(ymax float64) (float64, float64) {
+	step := niceStep(ymax, 5)
+	ymax = math.Ceil(ymax/step) * step
+	if ymax <= 0 {
+		ymax = 1
+	}
+	return ymax, step
+}
+
+// layoutChart lays out a chart of the graph. The caller must hold g.mu.
+func (g *Graph) layoutChart(def chartDef, opts chartOptions) plot {
+	p := plot{
+		Width:  opts.Width,
+		Height: opts.Height,
+		Title:  fmt.Sprintf("%s - %s (%s)", g.Title, def.Name, def.Unit),
+		Left:   70,
+		Top:    30,
+		Right:  float64(opts.Width) - 15,
+		Bottom: float64(opts.Height) - 25,
+	}
+	if def.Right != nil {
+		p.Title = fmt.Sprintf("%s - %s (%s, %s)", g.Title, def.Name, def.Unit, def.Right.Unit)
+		p.Right = float64(opts.Width) - 55
+	}
+
+	all := map[string][]graphPoints{}
+	for _, s := range g.series() {
+		all[s.Name] = s.Points
+	}
+
+	// select, clip and, for stacked charts, stack the series
+	type dataLine struct {
+		series chartSeries
+		color  color.RGBA
+		points []graphPoints
+		right  bool
+	}
+	var lines []dataLine
+	base := map[float64]float64{}
+	for i, s := range def.Series {
+		if !opts.wants(s.Name) {
+			continue
+		}
+		var points []graphPoints
+		for _, pt := range all[s.Name] {
+			if !opts.inRange(pt[0]) {
+				continue
+			}
+			if def.Stacked {
+				pt[1] += base[pt[0]]
+				base[pt[0]] = pt[1]
+			}
+			points = append(points, pt)
+		}
+		lines = append(lines, dataLine{s, chartColors[i%len(chartColors)], points, false})
+	}
+	if def.Right != nil {
+		for i, s := range def.Right.Series {
+			if !opts.wants(s.Name) {
+				continue
+			}
+			var points []graphPoints
+			for _, pt := range all[s.Name] {
+				if opts.inRange(pt[0]) {
+					points = append(points, pt)
+				}
+			}
+			lines = append(lines, dataLine{s, chartColors[(len(def.Series)+i)%len(chartColors)], points, true})
+		}
+	}
+
+	xmin, xmax, ymax, rmax := math.Inf(1), math.Inf(-1), 0.0, 0.0
+	for _, l := range lines {
+		for _, pt := range l.points {
+			xmin = math.Min(xmin, pt[0])
+			xmax = math.Max(xmax, pt[0])
+			if l.right {
+				rmax = math.Max(rmax, pt[1])
+			} else {
+				ymax = math.Max(ymax, pt[1])
+			}
+		}
+	}
+	if math.IsInf(xmin, 0) {
+		xmin, xmax = 0, 1
+	}
+	if xmax <= xmin {
+		xmax = xmin + 1
+	}
+	ymax, ystep := niceMax(ymax)
+	rmax, rstep := niceMax(rmax)
+
+	px := func(x float64) float64 { return p.Left + (x-xmin)/(xmax-xmin)*(p.Right-p.Left) }
+	py := func(y, top float64) float64 { return p.Bottom - y/top*(p.Bottom-p.Top) }
+
+	for _, l := range lines {
+		top := ymax
+		if l.right {
+			top = rmax
+		}
+		line := plotLine{Label: l.series.Label, Color: l.color}
+		for _, pt := range l.points {
+			line.Points = append(line.Points, [2]float64{px(pt[0]), py(pt[1], top)})
+		}
+		p.Lines = append(p.Lines, line)
+	}
+
+	xstep := niceStep(xmax-xmin, 8)
+	for x := math.Ceil(xmin/xstep) * xstep; x <= xmax; x += xstep {
+		p.XTicks = append(p.XTicks, plotTick{px(x), strconv.FormatFloat(x, 'g', 6, 64) + "s"})
+	}
+	for y := 0.0; y <= ymax; y += ystep {
+		p.YTicks = append(p.YTicks, plotTick{py(y, ymax), strconv.FormatFloat(y, 'g', 6, 64) + def.Unit})
+	}
+	if def.Right != nil {
+		for y := 0.0; y <= rmax; y += rstep {
+			p.RightTicks = append(p.RightTicks, plotTick{py(y, rmax), strconv.FormatFloat(y, 'g', 6, 64) + def.Right.Unit})
+		}
+	}
+
+	return p
+}
+
+func svgColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func (p plot) WriteSVG(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="11">`+"\n",
+		p.Width, p.Height, p.Width, p.Height)
+	fmt.Fprintf(bw, `<rect width="100%%" height="100%%" fill="#fff"/>`+"\n")
+	fmt.Fprintf(bw, `<text x="%g" y="15">%s</text>`+"\n", p.Left, html.EscapeString(p.Title))
+
+	for _, t := range p.YTicks {
+		fmt.Fprintf(bw, `<line x1="%g" y1="%g" x2="%g" y2="%g" stroke="#eee"/>`+"\n", p.Left, t.Pos, p.Right, t.Pos)
+		fmt.Fprintf(bw, `<text x="%g" y="%g" text-anchor="end">%s</text>`+"\n", p.Left-5, t.Pos+4, html.EscapeString(t.Label))
+	}
+	for _, t := range p.RightTicks {
+		fmt.Fprintf(bw, `<text x="%g" y="%g">%s</text>`+"\n", p.Right+5, t.Pos+4, html.EscapeString(t.Label))
+	}
+	for _, t := range p.XTicks {
+		fmt.Fprintf(bw, `<line x1="%g" y1="%g" x2="%g" y2="%g" stroke="#eee"/>`+"\n", t.Pos, p.Top, t.Pos, p.Bottom)
+		fmt.Fprintf(bw, `<text x="%g" y="%g" text-anchor="middle">%s</text>`+"\n", t.Pos, p.Bottom+15, html.EscapeString(t.Label))
+	}
+	fmt.Fprintf(bw, `<rect x="%g" y="%g" width="%g" height="%g" fill="none" stroke="#999"/>`+"\n",
+		p.Left, p.Top, p.Right-p.Left, p.Bottom-p.Top)
+
+	for i, l := range p.Lines {
+		var points []string
+		for _, pt := range l.Points {
+			points = append(points, fmt.Sprintf("%.1f,%.1f", pt[0], pt[1]))
+		}
+		fmt.Fprintf(bw, `<polyline fill="none" stroke="%s" stroke-width="2" points="%s"/>`+"\n", svgColor(l.Color), strings.Join(points, " "))
+
+		y := p.Top + 15 + float64(i)*14
+		fmt.Fprintf(bw, `<rect x="%g" y="%g" width="10" height="10" fill="%s"/>`+"\n", p.Left+10, y-9, svgColor(l.Color))
+		fmt.Fprintf(bw, `<text x="%g" y="%g">%s</text>`+"\n", p.Left+25, y, html.EscapeString(l.Label))
+	}
+
+	fmt.Fprintf(bw, "</svg>\n")
+	return bw.Flush()
+}
+
+func (p plot) WritePNG(w io.Writer) error {
+	img := image.NewRGBA(image.Rect(0, 0, p.Width, p.Height))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	grid := color.RGBA{0xee, 0xee, 0xee, 0xff}
+	frame := color.RGBA{0x99, 0x99, 0x99, 0xff}
+
+	text := func(x, y float64, s string, anchor float64) {
+		d := &font.Drawer{Dst: img, Src: image.Black, Face: basicfont.Face7x13}
+		width := d.MeasureString(s).Round()
+		d.Dot = fixed.P(int(x-anchor*float64(width)), int(y))
+		d.DrawString(s)
+	}
+
+	for _, t := range p.YTicks {
+		drawLine(img, p.Left, t.Pos, p.Right, t.Pos, grid)
+		text(p.Left-5, t.Pos+4, t.Label, 1)
+	}
+	for _, t := range p.RightTicks {
+		text(p.Right+5, t.Pos+4, t.Label, 0)
+	}
+	for _, t := range p.XTicks {
+		drawLine(img, t.Pos, p.Top, t.Pos, p.Bottom, grid)
+		text(t.Pos, p.Bottom+15, t.Label, 0.5)
+	}
+	drawLine(img, p.Left, p.Top, p.Right, p.Top, frame)
+	drawLine(img, p.Left, p.Bottom, p.Right, p.Bottom, frame)
+	drawLine(img, p.Left, p.Top, p.Left, p.Bottom, frame)
+	drawLine(img, p.Right, p.Top, p.Right, p.Bottom, frame)
+
+	text(p.Left, 15, p.Title, 0)
+
+	for i, l := range p.Lines {
+		for j := 1; j < len(l.Points); j++ {
+			a, b := l.Points[j-1], l.Points[j]
+			drawLine(img, a[0], a[1], b[0], b[1], l.Color)
+			drawLine(img, a[0], a[1]+1, b[0], b[1]+1, l.Color)
+		}
+
+		y := p.Top + 15 + float64(i)*14
+		draw.Draw(img, image.Rect(int(p.Left+10), int(y-9), int(p.Left+20), int(y+1)), image.NewUniform(l.Color), image.Point{}, draw.Src)
+		text(p.Left+25, y, l.Label, 0)
+	}
+
+	return png.Encode(w, img)
+}
+
+// drawLine draws a one pixel wide line with Bresenham's algorithm.
+func drawLine(img *image.RGBA, x0f, y0f, x1f, y1f float64, c color.RGBA) {
+	x0, y0, x1, y1 := int(math.Round(x0f)), int(math.Round(y0f)), int(math.Round(x1f)), int(math.Round(y1f))
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		img.SetRGBA(x0, y0, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// WriteChart renders the named chart as SVG or PNG.
+func (g *Graph) WriteChart(w io.Writer, name, format string, opts chartOptions) error {
+	def, ok := findChartDef(name)
+	if !ok {
+		return fmt.Errorf("unknown chart %q", name)
+	}
+
+	g.mu.RLock()
+	p := g.layoutChart(def, opts)
+	g.mu.RUnlock()
+
+	switch format {
+	case "svg":
+		return p.WriteSVG(w)
+	case "png":
+		return p.WritePNG(w)
+	}
+	return fmt.Errorf("unknown chart format %q", format)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"image/png"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func chartGraph() *Graph {
+	graph := NewGraph("fake title", GCVIS_TMPL)
+	for i := 1; i <= 20; i++ {
+		graph.AddGCTraceGraphPoint(&gctrace{ElapsedTime: float64(i), Heap1: int64(i), STWSclock: 1, MASclock: 2})
+	}
+	return &graph
+}
+
+func TestParseChartOptions(t *testing.T) {
+	q, _ := url.ParseQuery("width=800&height=200&from=5&to=10&series=HeapUse,ScvgSys")
+	opts, err := parseChartOptions(q)
+	if err != nil {
+		t.Fatalf("parseChartOptions returned an error: %v", err)
+	}
+	if opts.Width != 800 || opts.Height != 200 || opts.From != 5 || opts.To != 10 || len(opts.Series) != 2 {
+		t.Errorf("Unexpected chart options: %+v", opts)
+	}
+
+	for _, query := range []string{"width=huge", "width=10000&height=10000"} {
+		q, _ = url.ParseQuery(query)
+		if _, err := parseChartOptions(q); err == nil {
+			t.Errorf("Expected %q to be rejected.", query)
+		}
+	}
+}
+
+func TestLayoutChartRightAxis(t *testing.T) {
+	graph := NewGraph("fake title", GCVIS_TMPL)
+	graph.AddGCTraceGraphPoint(&gctrace{ElapsedTime: 1, CPUPercent: 2, Heap0: 8, HeapLive: 4, Nproc: 4})
+	graph.AddGCTraceGraphPoint(&gctrace{ElapsedTime: 3, CPUPercent: 40, Heap0: 1000, HeapLive: 5, Nproc: 4})
+	def, _ := findChartDef("rate")
+
+	p := graph.layoutChart(def, chartOptions{Width: 400, Height: 200})
+	if len(p.Lines) != 2 || p.Lines[1].Label != "gc cpu" {
+		t.Fatalf("Expected alloc rate and gc cpu lines. Got %+v.", p.Lines)
+	}
+	if len(p.RightTicks) == 0 || !strings.HasSuffix(p.RightTicks[0].Label, "%") {
+		t.Errorf("Expected gc cpu to have its own axis in %%. Got %+v.", p.RightTicks)
+	}
+
+	// each line reaches the top of its own axis
+	for _, l := range p.Lines {
+		if top := l.Points[len(l.Points)-1][1]; top > p.Top+(p.Bottom-p.Top)/2 {
+			t.Errorf("Expected %s to be scaled to its own axis. Its last point is at %v.", l.Label, top)
+		}
+	}
+}
+
+func TestLayoutChartStacksAndClips(t *testing.T) {
+	graph := chartGraph()
+	def, _ := findChartDef("clock")
+
+	p := graph.layoutChart(def, chartOptions{Width: 400, Height: 200, From: 5, To: 10})
+	if len(p.Lines) != 3 {
+		t.Fatalf("Expected 3 lines. Got %d.", len(p.Lines))
+	}
+	if n := len(p.Lines[0].Points); n != 6 {
+		t.Errorf("Expected the range to keep 6 points. Got %d.", n)
+	}
+
+	// STW sweep is stacked under con mas, which is drawn higher up
+	if p.Lines[1].Points[0][1] >= p.Lines[0].Points[0][1] {
+		t.Errorf("Expected stacked series to be drawn above the one below it.")
+	}
+
+	p = graph.layoutChart(def, chartOptions{Width: 400, Height: 200, Series: []string{"MASclock"}})
+	if len(p.Lines) != 1 || p.Lines[0].Label != "con mas clock" {
+		t.Errorf("Expected series selection to keep only con mas clock. Got %+v.", p.Lines)
+	}
+}
+
+func TestGraphWriteChart(t *testing.T) {
+	graph := chartGraph()
+	opts := chartOptions{Width: 400, Height: 200}
+
+	w := &bytes.Buffer{}
+	if err := graph.WriteChart(w, "heap", "svg", opts); err != nil {
+		t.Fatalf("WriteChart returned an error: %v", err)
+	}
+	if !strings.HasPrefix(w.String(), "<svg") || !strings.Contains(w.String(), "gc.heapinuse") {
+		t.Errorf("Unexpected SVG: %v", w.String())
+	}
+
+	w.Reset()
+	if err := graph.WriteChart(w, "cpu", "png", opts); err != nil {
+		t.Fatalf("WriteChart returned an error: %v", err)
+	}
+	img, err := png.Decode(w)
+	if err != nil {
+		t.Fatalf("PNG didn't decode: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 400 || b.Dy() != 200 {
+		t.Errorf("Expected a 400x200 image. Got %v.", b)
+	}
+
+	if err := graph.WriteChart(w, "nope", "svg", opts); err == nil {
+		t.Errorf("Expected an unknown chart to be rejected.")
+	}
+}
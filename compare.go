@@ -0,0 +1,302 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+)
+
+var compareTmpl = template.Must(template.New("compare").Parse(COMPARE_TMPL))
+
+// LoadSession reads a whole session into a Graph titled with the file
+// name. The file is either a recording made with -record or a raw log
+// of gctrace lines, in which case the traces' own timestamps are used.
+func LoadSession(filename string, mark *regexp.Regexp) (*Graph, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var source lineSource
+	if entries, err := readRecording(bytes.NewReader(data)); err == nil {
+		source = &entrySource{entries: entries}
+	} else {
+		source = &readerSource{sc: bufio.NewScanner(bytes.NewReader(data)), start: StartTime}
+	}
+
+	parser := newParser(source)
+	parser.Mark = mark
+	graph, err := collectSession(filename, parser, ioutil.Discard)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return graph, nil
+}
+
+// collectSession runs parser, which must not have been started, to the
+// end of its input and gathers everything into a Graph. Lines that are
+// not traces are written to output.
+func collectSession(title string, parser *Parser, output io.Writer) (*Graph, error) {
+	graph := NewGraph(title, GCVIS_TMPL)
+	return &graph, collect(&graph, parser, output)
+}
+
+// collect runs parser as collectSession does, into graph, which may be
+// read while it fills.
+func collect(graph *Graph, parser *Parser, output io.Writer) error {
+	// Unbuffered channels make sure every trace has been taken before
+	// done is closed.
+	parser.GcChan = make(chan *gctrace)
+	parser.ScvgChan = make(chan *scvgtrace)
+	parser.NoMatchChan = make(chan string)
+	parser.AnnotationChan = make(chan Annotation)
+	go parser.Run()
+
+	for {
+		select {
+		case gcTrace := <-parser.GcChan:
+			graph.AddGCTraceGraphPoint(gcTrace)
+		case scvgTrace := <-parser.ScvgChan:
+			graph.AddScavengerGraphPoint(scvgTrace)
+		case line := <-parser.NoMatchChan:
+			fmt.Fprintln(output, line)
+		case annotation := <-parser.AnnotationChan:
+			graph.AddAnnotation(annotation)
+		case <-parser.ResetChan:
+			graph.Reset()
+		case <-parser.done:
+			return parser.Err
+		}
+	}
+}
+
+// A Comparison sets sessions against the first of them. All are placed
+// on the same timeline, starting at the start of their run.
+type Comparison struct {
+	Sessions []*Graph
+
+	// Live comparisons are of sessions still being collected, which the
+	// page keeps pulling.
+	Live bool
+}
+
+func NewComparison(sessions ...*Graph) *Comparison {
+	return &Comparison{Sessions: sessions}
+}
+
+func (c *Comparison) Title() string {
+	titles := make([]string, len(c.Sessions))
+	for i, g := range c.Sessions {
+		titles[i] = g.Title
+	}
+	return strings.Join(titles, " vs ")
+}
+
+// A statDelta is the change of one statistic between two sessions.
+type statDelta struct {
+	Name   string
+	Unit   string
+	Before float64
+	After  float64
+}
+
+func (d statDelta) Delta() float64 {
+	return d.After - d.Before
+}
+
+// Change is the relative change, or n/a when there is nothing to
+// compare against.
+func (d statDelta) Change() string {
+	if d.Before == 0 {
+		return "n/a"
+	}
+	return fmt.Sprintf("%+.1f%%", d.Delta()/d.Before*100)
+}
+
+// A statRow holds one statistic of every session of a Comparison.
+type statRow struct {
+	Key    string
+	Name   string
+	Unit   string
+	Values []float64
+}
+
+// Delta is the change of the statistic from the first session to
+// session i.
+func (r statRow) Delta(i int) statDelta {
+	return statDelta{Name: r.Name, Unit: r.Unit, Before: r.Values[0], After: r.Values[i]}
+}
+
+// reportStats are the statistics of a whole run that sessions are
+// compared on. Key names them in baseline files and on the command line.
+var reportStats = []struct {
+	Key   string
+	Name  string
+	Unit  string
+	Value func(r Report) float64
+}{
+	{"cycles", "cycles", "", func(r Report) float64 { return float64(r.Summary.Cycles) }},
+	{"wall", "wall time", "s", func(r Report) float64 { return r.Summary.WallTime }},
+	{"rate", "GCs per minute", "", func(r Report) float64 { return r.Summary.GCPerMinute }},
+	{"interval", "mean interval", "s", func(r Report) float64 { return r.Summary.MeanInterval }},
+	{"p50", "pause p50", "ms", func(r Report) float64 { return r.Summary.PauseP50 }},
+	{"p95", "pause p95", "ms", func(r Report) float64 { return r.Summary.PauseP95 }},
+	{"p99", "pause p99", "ms", func(r Report) float64 { return r.Summary.PauseP99 }},
+	{"pausemax", "pause max", "ms", func(r Report) float64 { return r.Summary.PauseMax }},
+	{"cpu", "GC cpu", "%", func(r Report) float64 { return r.Summary.GCCPUFraction * 100 }},
+	{"assist", "assist share", "%", func(r Report) float64 { return r.Summary.AssistShare * 100 }},
+	{"goal", "peak heap goal", "MB", func(r Report) float64 { return float64(r.PeakGoal) }},
+	{"live", "live heap peak", "MB", func(r Report) float64 { return float64(r.PeakLive) }},
+}
+
+func (c *Comparison) Stats() []statRow {
+	reports := make([]Report, len(c.Sessions))
+	for i, g := range c.Sessions {
+		reports[i] = g.Report()
+	}
+
+	rows := make([]statRow, len(reportStats))
+	for i, s := range reportStats {
+		rows[i] = statRow{Key: s.Key, Name: s.Name, Unit: s.Unit, Values: make([]float64, len(reports))}
+		for j, r := range reports {
+			rows[i].Values[j] = s.Value(r)
+		}
+	}
+	return rows
+}
+
+// WriteTable writes every statistic of every session, along with its
+// change from the first session.
+func (c *Comparison) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+
+	for i, g := range c.Sessions {
+		fmt.Fprintf(tw, "\t%s", g.Title)
+		if i > 0 {
+			fmt.Fprintf(tw, "\tchange")
+		}
+	}
+	fmt.Fprintf(tw, "\t\n")
+
+	for _, row := range c.Stats() {
+		fmt.Fprintf(tw, "%s", row.Name)
+		for i, v := range row.Values {
+			fmt.Fprintf(tw, "\t%.4g%s", v, row.Unit)
+			if i > 0 {
+				fmt.Fprintf(tw, "\t%s", row.Delta(i).Change())
+			}
+		}
+		fmt.Fprintf(tw, "\t\n")
+	}
+
+	return tw.Flush()
+}
+
+// compareSeries holds the series of one session drawn on the
+// comparison page. Its times count from its first trace, so that
+// sessions line up on the start of their run, even a log that begins
+// part way through one.
+type compareSeries struct {
+	Title     string
+	Ended     string
+	WarmupEnd float64
+	Goal      []graphPoints
+	Live      []graphPoints
+	Pause     []graphPoints
+}
+
+func (g *Graph) compareSeries() compareSeries {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	s := compareSeries{
+		Title: g.Title,
+		Ended: g.Ended,
+		Goal:  []graphPoints{},
+		Live:  []graphPoints{},
+		Pause: []graphPoints{},
+	}
+	if len(g.gcTraces) == 0 {
+		return s
+	}
+
+	start := g.gcTraces[0].ElapsedTime
+	if g.WarmupEnd > start {
+		s.WarmupEnd = g.WarmupEnd - start
+	}
+	for _, t := range g.gcTraces {
+		x := t.ElapsedTime - start
+		s.Goal = append(s.Goal, graphPoints{x, float64(t.Heap1)})
+		s.Live = append(s.Live, graphPoints{x, float64(t.HeapLive)})
+		s.Pause = append(s.Pause, graphPoints{x, pauseMs(t)})
+	}
+	return s
+}
+
+type comparePage struct {
+	Title    string
+	Sessions []compareSeries
+	Stats    []statRow
+	Scripts  []pageScript
+	Live     bool
+}
+
+func (c *Comparison) series() []compareSeries {
+	var series []compareSeries
+	for _, g := range c.Sessions {
+		series = append(series, g.compareSeries())
+	}
+	return series
+}
+
+func (c *Comparison) write(w io.Writer, scripts []pageScript, live bool) error {
+	return compareTmpl.Execute(w, comparePage{
+		Title:    c.Title(),
+		Sessions: c.series(),
+		Stats:    c.Stats(),
+		Scripts:  scripts,
+		Live:     live,
+	})
+}
+
+func (c *Comparison) Write(w io.Writer) error {
+	return c.write(w, liveScripts(), c.Live)
+}
+
+// WriteHTMLReport writes the comparison page with every script inlined,
+// so that it can be opened without gcvis running.
+func (c *Comparison) WriteHTMLReport(w io.Writer) error {
+	scripts, err := inlineScripts()
+	if err != nil {
+		return err
+	}
+	return c.write(w, scripts, false)
+}
+
+func (c *Comparison) Handler() http.Handler {
+	serveMux := http.NewServeMux()
+
+	serveMux.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+		c.Write(w)
+	})
+
+	serveMux.Handle("/static/", http.FileServer(http.FS(assetFiles)))
+
+	serveMux.HandleFunc("/comparison.json", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(struct {
+			Sessions []compareSeries
+			Stats    []statRow
+		}{c.series(), c.Stats()})
+	})
+
+	return serveMux
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeTempFile(t *testing.T, name, content string) string {
+	filename := filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatalf("Error writing %s: %v", filename, err)
+	}
+	return filename
+}
+
+func TestLoadSession(t *testing.T) {
+	recording := writeTempFile(t, "before.rec",
+		"1.000000\tgc 1 @1.000s 1%: 0.1+1+0.1 ms clock, 0.1+1/1/1+0.1 ms cpu, 4->5->2 MB, 5 MB goal, 4 P\n"+
+			"a1.500000\tphase two\n"+
+			"2.000000\tgc 2 @2.000s 1%: 0.2+1+0.2 ms clock, 0.1+1/1/1+0.1 ms cpu, 5->6->3 MB, 6 MB goal, 4 P\n")
+	log := writeTempFile(t, "after.log",
+		"starting\n"+
+			"gc 1 @0.500s 1%: 0.1+1+0.1 ms clock, 0.1+1/1/1+0.1 ms cpu, 4->5->2 MB, 8 MB goal, 4 P\n")
+
+	before, err := LoadSession(recording, nil)
+	if err != nil {
+		t.Fatalf("LoadSession returned an error: %v", err)
+	}
+	if len(before.gcTraces) != 2 || len(before.Annotations) != 1 {
+		t.Errorf("Expected 2 traces and 1 annotation. Got %d and %d instead.", len(before.gcTraces), len(before.Annotations))
+	}
+
+	after, err := LoadSession(log, nil)
+	if err != nil {
+		t.Fatalf("LoadSession returned an error: %v", err)
+	}
+	if len(after.gcTraces) != 1 || after.gcTraces[0].ElapsedTime != 0.5 {
+		t.Fatalf("Expected the log's trace at 0.5s. Got %+v instead.", after.gcTraces)
+	}
+
+	if _, err := LoadSession(filepath.Join(t.TempDir(), "missing"), nil); !os.IsNotExist(err) {
+		t.Errorf("Expected a missing file to fail. Got %v instead.", err)
+	}
+}
+
+func TestComparisonStats(t *testing.T) {
+	defer bundleStubScripts()()
+
+	before := NewGraph("before", GCVIS_TMPL)
+	before.AddGCTraceGraphPoint(&gctrace{ElapsedTime: 1, Heap1: 10, STWSclock: 1, Nproc: 1})
+	before.AddGCTraceGraphPoint(&gctrace{ElapsedTime: 2, Heap1: 20, STWSclock: 1, Nproc: 1})
+	after := NewGraph("after", GCVIS_TMPL)
+	after.AddGCTraceGraphPoint(&gctrace{ElapsedTime: 1, Heap1: 15, STWSclock: 2, Nproc: 1})
+	third := NewGraph("third", GCVIS_TMPL)
+	third.AddGCTraceGraphPoint(&gctrace{ElapsedTime: 1, Heap1: 30, STWSclock: 1, Nproc: 1})
+
+	comparison := NewComparison(&before, &after, &third)
+
+	rows := map[string]statRow{}
+	for _, row := range comparison.Stats() {
+		rows[row.Key] = row
+	}
+	if d := rows["goal"].Delta(1); d.Before != 20 || d.After != 15 || d.Change() != "-25.0%" {
+		t.Errorf("Expected peak heap goal to go from 20 to 15 (-25.0%%). Got %+v (%v) instead.", d, d.Change())
+	}
+	if d := rows["goal"].Delta(2); d.Change() != "+50.0%" {
+		t.Errorf("Expected peak heap goal to grow by 50%% in the third session. Got %v instead.", d.Change())
+	}
+	if d := rows["pausemax"].Delta(1); d.Delta() != 1 {
+		t.Errorf("Expected pause max to grow by 1ms. Got %v instead.", d.Delta())
+	}
+
+	w := &bytes.Buffer{}
+	if err := comparison.WriteTable(w); err != nil {
+		t.Fatalf("WriteTable returned an error: %v", err)
+	}
+	if !strings.Contains(w.String(), "-25.0%") || !strings.Contains(w.String(), "+50.0%") {
+		t.Errorf("Expected the table to show the changes. Got:\n%v", w.String())
+	}
+
+	w.Reset()
+	if err := comparison.WriteHTMLReport(w); err != nil {
+		t.Fatalf("WriteHTMLReport returned an error: %v", err)
+	}
+	if !strings.Contains(w.String(), "before vs after vs third") || strings.Contains(w.String(), `src="/static/`) {
+		t.Errorf("Expected a self-contained comparison page. Got:\n%v", w.String())
+	}
+}
+
+func TestCompareSeriesAligned(t *testing.T) {
+	graph := NewGraph("mid-run", GCVIS_TMPL)
+	graph.AddGCTraceGraphPoint(&gctrace{ElapsedTime: 3600, Heap1: 10, HeapLive: 4})
+	graph.AddGCTraceGraphPoint(&gctrace{ElapsedTime: 3602.5, Heap1: 12, HeapLive: 5})
+
+	expected := []graphPoints{{0, 10}, {2.5, 12}}
+	if s := graph.compareSeries(); !reflect.DeepEqual(s.Goal, expected) {
+		t.Errorf("Expected Goal to equal %v. Got %v instead.", expected, s.Goal)
+	}
+
+	graph.SetWarmup(Warmup{Cycles: 2})
+	if s := graph.compareSeries(); s.WarmupEnd != 2.5 {
+		t.Errorf("Expected WarmupEnd to equal 2.5. Got %v instead.", s.WarmupEnd)
+	}
+}
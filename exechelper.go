@@ -0,0 +1,45 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strings"
+)
+
+// execHelperFlag, as gcvis's first argument, has it start a program with
+// its GODEBUG set, as the -exec program of go run and go test:
+//
+//	gcvis -exec-helper GODEBUG program [arguments]...
+const execHelperFlag = "-exec-helper"
+
+// execHelper returns the -exec value that has go run and go test start
+// the program they build through gcvis, with GODEBUG set to godebug. It
+// returns false if gcvis cannot find its own binary.
+func execHelper(godebug string) (string, bool) {
+	self, err := os.Executable()
+	if err != nil {
+		return "", false
+	}
+	return quoteExecArg(self) + " " + execHelperFlag + " " + quoteExecArg(godebug), true
+}
+
+// quoteExecArg quotes s for go's -exec flag, which splits its value at
+// spaces outside of quotes, and knows no escapes.
+func quoteExecArg(s string) string {
+	switch {
+	case s != "" && !strings.ContainsAny(s, " \t\n\r'\""):
+		return s
+	case strings.Contains(s, "'"):
+		return `"` + s + `"`
+	}
+	return "'" + s + "'"
+}
+
+// runExecHelper starts the program in args with GODEBUG set to godebug,
+// in place of gcvis where the system allows.
+func runExecHelper(godebug string, args []string) {
+	os.Setenv("GODEBUG", godebug)
+	if err := execProgram(args); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+)
+
+// execProgram runs the program in args and exits with its status, as
+// Windows cannot replace gcvis with it.
+func execProgram(args []string) error {
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+	// Ctrl-C reaches every process of the console, the program included,
+	// which decides what to do with it
+	signal.Ignore(os.Interrupt)
+
+	if err := cmd.Run(); err != nil {
+		if exit, ok := err.(*exec.ExitError); ok {
+			os.Exit(exit.ExitCode())
+		}
+		return err
+	}
+	os.Exit(0)
+	return nil
+}
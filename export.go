@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+)
+
+type gcColumn struct {
+	Name  string
+	Value func(*gctrace) float64
+	Text  func(*gctrace) string // set instead of Value for a column of text
+}
+
+func (c gcColumn) format(t *gctrace) string {
+	if c.Text != nil {
+		return c.Text(t)
+	}
+	return formatFloat(c.Value(t))
+}
+
+func (c gcColumn) formatJSON(t *gctrace) string {
+	if c.Text != nil {
+		text, _ := json.Marshal(c.Text(t))
+		return string(text)
+	}
+	return formatFloat(c.Value(t))
+}
+
+// gcColumns are the fields exported for every GC cycle, named after the
+// gctrace fields they come from.
+var gcColumns = []gcColumn{
+	{"ElapsedTime", func(t *gctrace) float64 { return t.ElapsedTime }, nil},
+	{"NumGC", func(t *gctrace) float64 { return float64(t.NumGC) }, nil},
+	{"Run", func(t *gctrace) float64 { return float64(t.Run) }, nil},
+	{"CPUPercent", func(t *gctrace) float64 { return float64(t.CPUPercent) }, nil},
+	{"Heap0", func(t *gctrace) float64 { return float64(t.Heap0) }, nil},
+	{"Heap1", func(t *gctrace) float64 { return float64(t.Heap1) }, nil},
+	{"HeapLive", func(t *gctrace) float64 { return float64(t.HeapLive) }, nil},
+	{"Nproc", func(t *gctrace) float64 { return float64(t.Nproc) }, nil},
+	{"STWSclock", func(t *gctrace) float64 { return t.STWSclock }, nil},
+	{"MASclock", func(t *gctrace) float64 { return t.MASclock }, nil},
+	{"STWMclock", func(t *gctrace) float64 { return t.STWMclock }, nil},
+	{"STWScpu", func(t *gctrace) float64 { return t.STWScpu }, nil},
+	{"MASAssistcpu", func(t *gctrace) float64 { return t.MASAssistcpu }, nil},
+	{"MASBGcpu", func(t *gctrace) float64 { return t.MASBGcpu }, nil},
+	{"MASIdlecpu", func(t *gctrace) float64 { return t.MASIdlecpu }, nil},
+	{"STWMcpu", func(t *gctrace) float64 { return t.STWMcpu }, nil},
+	{"Benchmark", nil, func(t *gctrace) string { return t.Benchmark }},
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// eachRow calls cycle for every GC cycle and annotate for every
+// annotation, in time order. The caller must hold g.mu.
+func (g *Graph) eachRow(cycle func(*gctrace), annotate func(Annotation)) {
+	annotations := g.Annotations
+	for _, trace := range g.gcTraces {
+		for len(annotations) > 0 && annotations[0].ElapsedTime < trace.ElapsedTime {
+			annotate(annotations[0])
+			annotations = annotations[1:]
+		}
+		cycle(trace)
+	}
+	for _, a := range annotations {
+		annotate(a)
+	}
+}
+
+// WriteCSV writes one row per GC cycle, with a header naming each field.
+// Annotations get rows of their own, holding only their time and label.
+func (g *Graph) WriteCSV(w io.Writer) error {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	cw := csv.NewWriter(w)
+
+	row := make([]string, len(gcColumns)+1)
+	for i, col := range gcColumns {
+		row[i] = col.Name
+	}
+	row[len(gcColumns)] = "Annotation"
+	cw.Write(row)
+
+	g.eachRow(func(trace *gctrace) {
+		for i, col := range gcColumns {
+			row[i] = col.format(trace)
+		}
+		row[len(gcColumns)] = ""
+		cw.Write(row)
+	}, func(a Annotation) {
+		for i := range row {
+			row[i] = ""
+		}
+		row[0] = formatFloat(a.ElapsedTime)
+		row[len(gcColumns)] = a.Label
+		cw.Write(row)
+	})
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteNDJSON writes one JSON object per GC cycle and line. Annotations
+// are written as objects holding only their ElapsedTime and Annotation.
+func (g *Graph) WriteNDJSON(w io.Writer) error {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	bw := bufio.NewWriter(w)
+	g.eachRow(func(trace *gctrace) {
+		bw.WriteByte('{')
+		for i, col := range gcColumns {
+			if i > 0 {
+				bw.WriteByte(',')
+			}
+			fmt.Fprintf(bw, "%q:%s", col.Name, col.formatJSON(trace))
+		}
+		bw.WriteString("}\n")
+	}, func(a Annotation) {
+		label, _ := json.Marshal(a.Label)
+		fmt.Fprintf(bw, "{\"ElapsedTime\":%s,\"Annotation\":%s}\n", formatFloat(a.ElapsedTime), label)
+	})
+
+	return bw.Flush()
+}
+
+// WriteSeriesCSV writes every point of every series in long format, one
+// series, time and value per row.
+func (g *Graph) WriteSeriesCSV(w io.Writer) error {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"series", "time", "value"})
+	for _, s := range g.series() {
+		for _, p := range s.Points {
+			cw.Write([]string{s.Name, formatFloat(p[0]), formatFloat(p[1])})
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// exportFunc picks the per-cycle export format from a file extension:
+// .csv, or .ndjson/.jsonl.
+func exportFunc(filename string) (func(*Graph, io.Writer) error, error) {
+	switch filepath.Ext(filename) {
+	case ".csv":
+		return (*Graph).WriteCSV, nil
+	case ".ndjson", ".jsonl":
+		return (*Graph).WriteNDJSON, nil
+	}
+
+	return nil, fmt.Errorf("%s: unknown export format, use .csv, .ndjson or .jsonl", filename)
+}
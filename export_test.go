@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestGraphWriteCSV(t *testing.T) {
+	graph := NewGraph("fake title", GCVIS_TMPL)
+	graph.AddGCTraceGraphPoint(&gctrace{ElapsedTime: 1.5, NumGC: 4, Run: 2, Heap1: 10, STWSclock: 0.25, Benchmark: "BenchmarkAlloc-8"})
+
+	w := &bytes.Buffer{}
+	if err := graph.WriteCSV(w); err != nil {
+		t.Fatalf("WriteCSV returned an error: %v", err)
+	}
+
+	expected := "ElapsedTime,NumGC,Run,CPUPercent,Heap0,Heap1,HeapLive,Nproc,STWSclock,MASclock,STWMclock,STWScpu,MASAssistcpu,MASBGcpu,MASIdlecpu,STWMcpu,Benchmark,Annotation\n" +
+		"1.5,4,2,0,0,10,0,0,0.25,0,0,0,0,0,0,0,BenchmarkAlloc-8,\n"
+	if w.String() != expected {
+		t.Errorf("Expected CSV export to equal:\n%v\nGot:\n%v", expected, w.String())
+	}
+}
+
+func TestGraphWriteNDJSON(t *testing.T) {
+	graph := NewGraph("fake title", GCVIS_TMPL)
+	graph.AddGCTraceGraphPoint(&gctrace{ElapsedTime: 1.5, NumGC: 1, Run: 1, Heap1: 10, Benchmark: "BenchmarkAlloc-8"})
+	graph.AddGCTraceGraphPoint(&gctrace{ElapsedTime: 2, NumGC: 1, Run: 2, Heap1: 12})
+
+	w := &bytes.Buffer{}
+	if err := graph.WriteNDJSON(w); err != nil {
+		t.Fatalf("WriteNDJSON returned an error: %v", err)
+	}
+
+	expected := `{"ElapsedTime":1.5,"NumGC":1,"Run":1,"CPUPercent":0,"Heap0":0,"Heap1":10,"HeapLive":0,"Nproc":0,"STWSclock":0,"MASclock":0,"STWMclock":0,"STWScpu":0,"MASAssistcpu":0,"MASBGcpu":0,"MASIdlecpu":0,"STWMcpu":0,"Benchmark":"BenchmarkAlloc-8"}` + "\n" +
+		`{"ElapsedTime":2,"NumGC":1,"Run":2,"CPUPercent":0,"Heap0":0,"Heap1":12,"HeapLive":0,"Nproc":0,"STWSclock":0,"MASclock":0,"STWMclock":0,"STWScpu":0,"MASAssistcpu":0,"MASBGcpu":0,"MASIdlecpu":0,"STWMcpu":0,"Benchmark":""}` + "\n"
+	if w.String() != expected {
+		t.Errorf("Expected NDJSON export to equal:\n%v\nGot:\n%v", expected, w.String())
+	}
+}
+
+func TestGraphExportAnnotations(t *testing.T) {
+	graph := NewGraph("fake title", GCVIS_TMPL)
+	graph.AddGCTraceGraphPoint(&gctrace{ElapsedTime: 1})
+	graph.AddGCTraceGraphPoint(&gctrace{ElapsedTime: 3})
+	graph.AddAnnotation(NewAnnotation(2, "deploy v2"))
+	graph.AddAnnotation(NewAnnotation(4, `cache "flush"`))
+
+	w := &bytes.Buffer{}
+	if err := graph.WriteNDJSON(w); err != nil {
+		t.Fatalf("WriteNDJSON returned an error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(w.String()), "\n")
+	if len(lines) != 4 || lines[1] != `{"ElapsedTime":2,"Annotation":"deploy v2"}` || lines[3] != `{"ElapsedTime":4,"Annotation":"cache \"flush\""}` {
+		t.Errorf("Expected annotations between the cycles. Got:\n%v", w.String())
+	}
+
+	w.Reset()
+	if err := graph.WriteCSV(w); err != nil {
+		t.Fatalf("WriteCSV returned an error: %v", err)
+	}
+	if !strings.Contains(w.String(), "\n2,,,,,,,,,,,,,,,,,deploy v2\n") {
+		t.Errorf("Expected an annotation row. Got:\n%v", w.String())
+	}
+}
+
+func TestGraphWriteSeriesCSV(t *testing.T) {
+	graph := NewGraph("fake title", GCVIS_TMPL)
+	graph.AddScavengerGraphPoint(&scvgtrace{ElapsedTime: 3, inuse: 7})
+
+	w := &bytes.Buffer{}
+	if err := graph.WriteSeriesCSV(w); err != nil {
+		t.Fatalf("WriteSeriesCSV returned an error: %v", err)
+	}
+
+	expected := "series,time,value\n" +
+		"ScvgInuse,3,7\n" +
+		"ScvgIdle,3,0\n" +
+		"ScvgSys,3,0\n" +
+		"ScvgReleased,3,0\n" +
+		"ScvgConsumed,3,0\n"
+	if w.String() != expected {
+		t.Errorf("Expected series export to equal:\n%v\nGot:\n%v", expected, w.String())
+	}
+}
+
+func TestExportFunc(t *testing.T) {
+	for _, name := range []string{"out.csv", "out.ndjson", "out.jsonl"} {
+		if _, err := exportFunc(name); err != nil {
+			t.Errorf("exportFunc(%q) returned an error: %v", name, err)
+		}
+	}
+
+	if _, err := exportFunc("out.xml"); err == nil {
+		t.Errorf("Expected exportFunc to reject an unknown extension.")
+	}
+}
@@ -11,6 +11,7 @@ type graphPoints [2]float64
 
 type Graph struct {
 	Title                               string
+	Env                                 []string
 	HeapUse, ScvgInuse, ScvgIdle        []graphPoints
 	ScvgSys, ScvgReleased, ScvgConsumed []graphPoints
 	STWSclock                           []graphPoints
@@ -21,30 +22,27 @@ type Graph struct {
 	MASBGcpu                            []graphPoints
 	MASIdlecpu                          []graphPoints
 	STWMcpu                             []graphPoints
+	AllocRate                           []graphPoints
+	GCCPUPercent                        []graphPoints
+	Breaches                            []Breach
+	Annotations                         []Annotation
+	Ended                               string
+	WarmupEnd                           float64
 	Tmpl                                *template.Template `json:"-"`
 	mu                                  sync.RWMutex       `json:"-"`
+
+	gcTraces   []*gctrace
+	scvgTraces []*scvgtrace
+	warmup     Warmup
 }
 
 var StartTime = time.Now()
 
 func NewGraph(title, tmpl string) Graph {
 	g := Graph{
-		Title:        title,
-		HeapUse:      []graphPoints{},
-		ScvgInuse:    []graphPoints{},
-		ScvgIdle:     []graphPoints{},
-		ScvgSys:      []graphPoints{},
-		ScvgReleased: []graphPoints{},
-		ScvgConsumed: []graphPoints{},
-		STWSclock:    []graphPoints{},
-		MASclock:     []graphPoints{},
-		STWMclock:    []graphPoints{},
-		STWScpu:      []graphPoints{},
-		MASAssistcpu: []graphPoints{},
-		MASBGcpu:     []graphPoints{},
-		MASIdlecpu:   []graphPoints{},
-		STWMcpu:      []graphPoints{},
+		Title: title,
 	}
+	g.reset()
 	g.setTmpl(tmpl)
 
 	return g
@@ -54,21 +52,103 @@ func (g *Graph) setTmpl(tmplStr string) {
 	g.Tmpl = template.Must(template.New("vis").Parse(tmplStr))
 }
 
+// A page is what the template is executed with.
+type page struct {
+	*Graph
+	Scripts []pageScript
+
+	// Static pages carry everything they show and don't talk back to
+	// a server.
+	Static     bool
+	Summary    sessionSummary
+	Benchmarks []BenchmarkStats
+	Runs       []RunStats
+}
+
 func (g *Graph) Write(w io.Writer) error {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
-	return g.Tmpl.Execute(w, g)
+	return g.Tmpl.Execute(w, page{Graph: g, Scripts: liveScripts()})
 }
 
-func (g *Graph) AddGCTraceGraphPoint(gcTrace *gctrace) {
+// WriteHTMLReport writes a single page holding the data and the scripts
+// needed to view it, which can be opened without a running gcvis.
+func (g *Graph) WriteHTMLReport(w io.Writer) error {
+	scripts, err := inlineScripts()
+	if err != nil {
+		return err
+	}
+
+	summary := g.Summary()
+	benchmarks := g.Benchmarks()
+	runs := g.Runs()
+
 	g.mu.RLock()
 	defer g.mu.RUnlock()
+	return g.Tmpl.Execute(w, page{
+		Graph:      g,
+		Scripts:    scripts,
+		Static:     true,
+		Summary:    summary,
+		Benchmarks: benchmarks,
+		Runs:       runs,
+	})
+}
+
+// Reset discards every point collected so far.
+func (g *Graph) Reset() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.reset()
+}
+
+func (g *Graph) reset() {
+	g.gcTraces = nil
+	g.scvgTraces = nil
+	g.HeapUse = []graphPoints{}
+	g.ScvgInuse = []graphPoints{}
+	g.ScvgIdle = []graphPoints{}
+	g.ScvgSys = []graphPoints{}
+	g.ScvgReleased = []graphPoints{}
+	g.ScvgConsumed = []graphPoints{}
+	g.STWSclock = []graphPoints{}
+	g.MASclock = []graphPoints{}
+	g.STWMclock = []graphPoints{}
+	g.STWScpu = []graphPoints{}
+	g.MASAssistcpu = []graphPoints{}
+	g.MASBGcpu = []graphPoints{}
+	g.MASIdlecpu = []graphPoints{}
+	g.STWMcpu = []graphPoints{}
+	g.AllocRate = []graphPoints{}
+	g.GCCPUPercent = []graphPoints{}
+	g.Breaches = []Breach{}
+	g.Annotations = []Annotation{}
+	_, g.WarmupEnd = g.warmup.split(nil)
+}
+
+func (g *Graph) AddGCTraceGraphPoint(gcTrace *gctrace) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
 	var elapsedTime float64
 	if gcTrace.ElapsedTime == 0 {
 		elapsedTime = time.Now().Sub(StartTime).Seconds()
 	} else {
 		elapsedTime = gcTrace.ElapsedTime
 	}
+	stamped := *gcTrace
+	stamped.ElapsedTime = elapsedTime
+	var prev *gctrace
+	if n := len(g.gcTraces); n > 0 && g.gcTraces[n-1].Run == gcTrace.Run {
+		prev = g.gcTraces[n-1]
+	}
+	g.gcTraces = append(g.gcTraces, &stamped)
+	_, g.WarmupEnd = g.warmup.split(g.gcTraces)
+	for _, d := range derivedSeries {
+		if value, ok := d.Value(prev, &stamped); ok {
+			points := d.Points(g)
+			*points = append(*points, graphPoints{elapsedTime, value})
+		}
+	}
 	g.HeapUse = append(g.HeapUse, graphPoints{elapsedTime, float64(gcTrace.Heap1)})
 	g.STWSclock = append(g.STWSclock, graphPoints{elapsedTime, float64(gcTrace.STWSclock)})
 	g.MASclock = append(g.MASclock, graphPoints{elapsedTime, float64(gcTrace.MASclock)})
@@ -80,18 +160,102 @@ func (g *Graph) AddGCTraceGraphPoint(gcTrace *gctrace) {
 	g.STWMcpu = append(g.STWMcpu, graphPoints{elapsedTime, float64(gcTrace.STWMcpu)})
 }
 
+func (g *Graph) AddBreach(b Breach) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.Breaches = append(g.Breaches, b)
+}
+
+// SetEnded records how the input ended, for the page to show.
+func (g *Graph) SetEnded(message string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.Ended = message
+}
+
 func (g *Graph) AddScavengerGraphPoint(scvg *scvgtrace) {
-	g.mu.RLock()
-	defer g.mu.RUnlock()
+	g.mu.Lock()
+	defer g.mu.Unlock()
 	var elapsedTime float64
 	if scvg.ElapsedTime == 0 {
 		elapsedTime = time.Now().Sub(StartTime).Seconds()
 	} else {
 		elapsedTime = scvg.ElapsedTime
 	}
+	stampedScvg := *scvg
+	stampedScvg.ElapsedTime = elapsedTime
+	g.scvgTraces = append(g.scvgTraces, &stampedScvg)
 	g.ScvgInuse = append(g.ScvgInuse, graphPoints{elapsedTime, float64(scvg.inuse)})
 	g.ScvgIdle = append(g.ScvgIdle, graphPoints{elapsedTime, float64(scvg.idle)})
 	g.ScvgSys = append(g.ScvgSys, graphPoints{elapsedTime, float64(scvg.sys)})
 	g.ScvgReleased = append(g.ScvgReleased, graphPoints{elapsedTime, float64(scvg.released)})
 	g.ScvgConsumed = append(g.ScvgConsumed, graphPoints{elapsedTime, float64(scvg.consumed)})
 }
+
+// recentTraces returns the last n GC cycles.
+func (g *Graph) recentTraces(n int) []*gctrace {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if len(g.gcTraces) < n {
+		n = len(g.gcTraces)
+	}
+	return append([]*gctrace(nil), g.gcTraces[len(g.gcTraces)-n:]...)
+}
+
+type namedSeries struct {
+	Name   string
+	Points []graphPoints
+}
+
+// series lists every series of the graph in a stable order, named as in
+// graph.json. The caller must hold g.mu.
+func (g *Graph) series() []namedSeries {
+	return []namedSeries{
+		{"HeapUse", g.HeapUse},
+		{"ScvgInuse", g.ScvgInuse},
+		{"ScvgIdle", g.ScvgIdle},
+		{"ScvgSys", g.ScvgSys},
+		{"ScvgReleased", g.ScvgReleased},
+		{"ScvgConsumed", g.ScvgConsumed},
+		{"STWSclock", g.STWSclock},
+		{"MASclock", g.MASclock},
+		{"STWMclock", g.STWMclock},
+		{"STWScpu", g.STWScpu},
+		{"MASAssistcpu", g.MASAssistcpu},
+		{"MASBGcpu", g.MASBGcpu},
+		{"MASIdlecpu", g.MASIdlecpu},
+		{"STWMcpu", g.STWMcpu},
+		{"AllocRate", g.AllocRate},
+		{"GCCPUPercent", g.GCCPUPercent},
+	}
+}
+
+// A derived series is computed from each GC cycle and the one before
+// it, which is nil for the first cycle. Value reports false when the
+// cycle yields no point, as for Go 1.4 traces: they carry neither a heap
+// breakdown nor a CPU share, and are recognised by their missing P count.
+var derivedSeries = []struct {
+	Points func(g *Graph) *[]graphPoints
+	Value  func(prev, cur *gctrace) (float64, bool)
+}{
+	{func(g *Graph) *[]graphPoints { return &g.AllocRate }, allocRate},
+	{func(g *Graph) *[]graphPoints { return &g.GCCPUPercent }, gcCPUPercent},
+}
+
+// allocRate is the heap allocated between the end of the previous cycle
+// and the start of this one, in MB/s.
+func allocRate(prev, cur *gctrace) (float64, bool) {
+	if prev == nil || cur.Nproc == 0 || prev.Nproc == 0 {
+		return 0, false
+	}
+	elapsed := cur.ElapsedTime - prev.ElapsedTime
+	if elapsed <= 0 {
+		return 0, false
+	}
+	return float64(cur.Heap0-prev.HeapLive) / elapsed, true
+}
+
+func gcCPUPercent(prev, cur *gctrace) (float64, bool) {
+	return float64(cur.CPUPercent), cur.Nproc != 0
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGraphDerivedSeries(t *testing.T) {
+	graph := NewGraph("fake title", GCVIS_TMPL)
+	graph.AddGCTraceGraphPoint(&gctrace{ElapsedTime: 1, CPUPercent: 2, Heap0: 8, HeapLive: 4, Nproc: 4})
+	graph.AddGCTraceGraphPoint(&gctrace{ElapsedTime: 3, CPUPercent: 3, Heap0: 10, HeapLive: 5, Nproc: 4})
+
+	expectedAllocRate := []graphPoints{{3, 3}}
+	if !reflect.DeepEqual(graph.AllocRate, expectedAllocRate) {
+		t.Errorf("Expected AllocRate to equal %v. Got %v instead.", expectedAllocRate, graph.AllocRate)
+	}
+
+	expectedGCCPUPercent := []graphPoints{{1, 2}, {3, 3}}
+	if !reflect.DeepEqual(graph.GCCPUPercent, expectedGCCPUPercent) {
+		t.Errorf("Expected GCCPUPercent to equal %v. Got %v instead.", expectedGCCPUPercent, graph.GCCPUPercent)
+	}
+}
+
+func TestGraphDerivedSeriesGo14(t *testing.T) {
+	graph := NewGraph("fake title", GCVIS_TMPL)
+	graph.AddGCTraceGraphPoint(&gctrace{ElapsedTime: 1, Heap1: 3})
+	graph.AddGCTraceGraphPoint(&gctrace{ElapsedTime: 2, Heap1: 4})
+
+	if len(graph.AllocRate) != 0 || len(graph.GCCPUPercent) != 0 {
+		t.Errorf("Expected no derived points for Go 1.4 traces. Got %v and %v.", graph.AllocRate, graph.GCCPUPercent)
+	}
+}
+
+func TestGraphWriteHTMLReport(t *testing.T) {
+	defer bundleStubScripts()()
+
+	graph := NewGraph("fake title", GCVIS_TMPL)
+	graph.AddGCTraceGraphPoint(&gctrace{ElapsedTime: 1.5, Heap1: 42})
+
+	w := &bytes.Buffer{}
+	if err := graph.WriteHTMLReport(w); err != nil {
+		t.Fatalf("WriteHTMLReport returned an error: %v", err)
+	}
+
+	for _, expected := range []string{"[[1.5,42]]", "if ( true )", `"Cycles":1`} {
+		if !strings.Contains(w.String(), expected) {
+			t.Errorf("Expected HTML report to contain %q.", expected)
+		}
+	}
+	if strings.Contains(w.String(), `href="/graph.json"`) {
+		t.Errorf("Expected HTML report not to link back to the server.")
+	}
+}
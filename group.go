@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// commandList implements flag.Value so that -run can be repeated. Each
+// command is split into arguments at white space.
+type commandList [][]string
+
+func (c *commandList) String() string {
+	commands := make([]string, len(*c))
+	for i, args := range *c {
+		commands[i] = strings.Join(args, " ")
+	}
+	return strings.Join(commands, ", ")
+}
+
+func (c *commandList) Set(s string) error {
+	args := strings.Fields(s)
+	if len(args) == 0 {
+		return fmt.Errorf("command %q: expected a program to run", s)
+	}
+	*c = append(*c, args)
+	return nil
+}
+
+// A Group runs several commands at once, each traced into a session of
+// its own, so that they can be watched side by side on one timeline.
+type Group struct {
+	Commands [][]string
+	GODEBUG  string
+	Duration time.Duration // zero runs every command to completion
+	Mark     *regexp.Regexp
+
+	cmds []*SubCommand
+	wg   sync.WaitGroup
+	mu   sync.Mutex // keeps the lines of the commands apart
+}
+
+// Start starts every command and returns their sessions, which fill as
+// the commands run. The standard output of each command is written to
+// stdout, and what is not traces of its standard error to stderr, each
+// line prefixed with the number of the command.
+func (g *Group) Start(stdout, stderr io.Writer) []*Graph {
+	var sessions []*Graph
+	titles := map[string]int{}
+	for i, args := range g.Commands {
+		title := strings.Join(args, " ")
+		if titles[title]++; titles[title] > 1 {
+			title = fmt.Sprintf("%s (%d)", title, titles[title])
+		}
+
+		prefix := fmt.Sprintf("[%d] ", i+1)
+		out := &prefixWriter{w: stdout, prefix: prefix, mu: &g.mu}
+
+		cmd := NewSubCommand(args)
+		cmd.SetGODEBUG(g.GODEBUG)
+		if !cmd.capturesStdout() {
+			cmd.SetStdout(out)
+		}
+		cmd.SetStdin(nil) // the terminal, if any, is left to gcvis
+		if g.Duration > 0 {
+			cmd.StopAfter(g.Duration, stopGrace)
+		}
+		forwardSignals(cmd)
+		g.cmds = append(g.cmds, cmd)
+
+		graph := NewGraph(title, GCVIS_TMPL)
+		graph.Env = cmd.RuntimeEnv()
+		sessions = append(sessions, &graph)
+
+		parser := NewParser(cmd.PipeRead)
+		parser.Mark = g.Mark
+		prefixed := &prefixWriter{w: stderr, prefix: prefix, mu: &g.mu}
+
+		g.wg.Add(1)
+		go cmd.Run()
+		go func() {
+			defer g.wg.Done()
+			if err := collect(&graph, parser, prefixed); err != nil {
+				log.Printf("%s: %v", graph.Title, err)
+			}
+			out.Flush()
+			graph.SetEnded(cmd.ExitMessage())
+		}()
+	}
+	return sessions
+}
+
+// Wait waits for every command to finish and returns the status to exit
+// with: that of the first command to fail, if any.
+func (g *Group) Wait() int {
+	g.wg.Wait()
+	for _, cmd := range g.cmds {
+		if status := cmd.ExitCode(); status != 0 {
+			return status
+		}
+	}
+	return 0
+}
+
+// A prefixWriter writes each line written to it to w, behind prefix. A
+// line is held until it is complete, and writers sharing w share mu, so
+// that their lines do not run into each other.
+type prefixWriter struct {
+	w      io.Writer
+	prefix string
+	mu     *sync.Mutex
+	line   []byte
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.line = append(p.line, b...)
+	for {
+		i := bytes.IndexByte(p.line, '\n')
+		if i < 0 {
+			return len(b), nil
+		}
+		if _, err := io.WriteString(p.w, p.prefix+string(p.line[:i+1])); err != nil {
+			return 0, err
+		}
+		p.line = p.line[i+1:]
+	}
+}
+
+// Flush writes what is left of a last line that did not end in a
+// newline.
+func (p *prefixWriter) Flush() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.line) == 0 {
+		return nil
+	}
+	_, err := io.WriteString(p.w, p.prefix+string(p.line)+"\n")
+	p.line = nil
+	return err
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCommandList(t *testing.T) {
+	var commands commandList
+	for _, s := range []string{"./server -port 8081", "./server -port 8082"} {
+		if err := commands.Set(s); err != nil {
+			t.Fatalf("Set(%q) returned an error: %v", s, err)
+		}
+	}
+
+	if s := commands.String(); s != "./server -port 8081, ./server -port 8082" {
+		t.Errorf("Expected both commands to be kept. Got %q instead.", s)
+	}
+	if err := commands.Set("  "); err == nil {
+		t.Errorf("Expected an empty command to be refused.")
+	}
+}
+
+func TestGroupRun(t *testing.T) {
+	trace := "echo 'gc 1 @0.010s 1%: 0.25+1+0.25 ms clock, 0.1+1/1/1+0.1 ms cpu, 4->5->2 MB, 5 MB goal, 8 P' 1>&2"
+	group := &Group{
+		Commands: [][]string{
+			{"/usr/bin/env", "bash", "-c", trace + "; echo old 1>&2; printf 'out\\npart'"},
+			{"/usr/bin/env", "bash", "-c", trace + "; " + trace + "; exit 3"},
+		},
+		GODEBUG: DefaultGODEBUG,
+	}
+
+	stdout, output := &bytes.Buffer{}, &bytes.Buffer{}
+	sessions := group.Start(stdout, output)
+
+	status := make(chan int)
+	go func() { status <- group.Wait() }()
+
+	select {
+	case code := <-status:
+		if code != 3 {
+			t.Errorf("Expected the group to exit with the failing command's status 3. Got %d instead.", code)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Execution timed out.")
+	}
+
+	for i, expected := range []int{1, 2} {
+		if cycles := sessions[i].Report().Summary.Cycles; cycles != expected {
+			t.Errorf("Expected session %d to have %d cycles. Got %d instead.", i+1, expected, cycles)
+		}
+	}
+	if !strings.Contains(sessions[1].Ended, "status 3") {
+		t.Errorf("Expected the second session to record its exit. Got %q instead.", sessions[1].Ended)
+	}
+	if output.String() != "[1] old\n" {
+		t.Errorf("Expected output to be prefixed with its command. Got %q instead.", output.String())
+	}
+	if stdout.String() != "[1] out\n[1] part\n" {
+		t.Errorf("Expected standard output to be prefixed with its command. Got %q instead.", stdout.String())
+	}
+}
+
+func TestPrefixWriter(t *testing.T) {
+	w := &bytes.Buffer{}
+	p := &prefixWriter{w: w, prefix: "[2] ", mu: &sync.Mutex{}}
+	for _, s := range []string{"one\ntw", "o\n", "three"} {
+		if _, err := p.Write([]byte(s)); err != nil {
+			t.Fatalf("Write returned an error: %v", err)
+		}
+	}
+	if w.String() != "[2] one\n[2] two\n" {
+		t.Errorf("Expected only whole lines to be written. Got %q instead.", w.String())
+	}
+	p.Flush()
+	if w.String() != "[2] one\n[2] two\n[2] three\n" {
+		t.Errorf("Expected the last line to be written on Flush. Got %q instead.", w.String())
+	}
+}
@@ -6,12 +6,20 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"path"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
 
 type HttpServer struct {
 	graph    *Graph
+	replayer *Replayer
+	annotate func(Annotation)
+	now      func() float64
+	quit     chan bool
+	quitOnce sync.Once
 	listener net.Listener
 	iface    string
 	port     string
@@ -21,29 +29,117 @@ type HttpServer struct {
 
 func NewHttpServer(iface string, port string, graph *Graph) *HttpServer {
 	h := &HttpServer{
-		graph: graph,
-		iface: iface,
-		port:  port,
+		graph:    graph,
+		annotate: graph.AddAnnotation,
+		now:      func() float64 { return time.Now().Sub(StartTime).Seconds() },
+		quit:     make(chan bool),
+		iface:    iface,
+		port:     port,
 	}
 
 	return h
 }
 
+// SetReplayer enables the replay controls for a session fed from a
+// recording.
+func (h *HttpServer) SetReplayer(r *Replayer) {
+	h.replayer = r
+}
+
+// SetAnnotate sets where posted annotations go, instead of straight to
+// the graph.
+func (h *HttpServer) SetAnnotate(annotate func(Annotation)) {
+	h.annotate = annotate
+}
+
+// SetNow sets where annotations posted without a time are placed,
+// instead of at the time since gcvis started.
+func (h *HttpServer) SetNow(now func() float64) {
+	h.now = now
+}
+
+// Quit is closed once the page has asked gcvis to exit.
+func (h *HttpServer) Quit() <-chan bool {
+	return h.quit
+}
+
 func (h *HttpServer) Start() {
+	if missing := missingAssets(); len(missing) > 0 {
+		log.Printf("%s not bundled, loading from CDN; run go generate before building to bundle them", strings.Join(missing, ", "))
+	}
+
 	serveMux := http.NewServeMux()
 
 	serveMux.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
 		h.graph.Write(w)
 	})
 
+	serveMux.Handle("/static/", http.FileServer(http.FS(assetFiles)))
+
 	serveMux.HandleFunc("/graph.json", func(w http.ResponseWriter, req *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		encoder := json.NewEncoder(w)
+		h.graph.mu.RLock()
+		defer h.graph.mu.RUnlock()
 		if err := encoder.Encode(h.graph); err != nil {
 			log.Fatalf("An error occurred while serving JSON endpoint: %v", err)
 		}
 	})
 
+	serveMux.HandleFunc("/export.csv", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/csv")
+		h.graph.WriteCSV(w)
+	})
+
+	serveMux.HandleFunc("/export.ndjson", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		h.graph.WriteNDJSON(w)
+	})
+
+	serveMux.HandleFunc("/series.csv", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/csv")
+		h.graph.WriteSeriesCSV(w)
+	})
+
+	serveMux.HandleFunc("/summary.json", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(h.graph.Summary())
+	})
+
+	serveMux.HandleFunc("/benchmarks.json", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(h.graph.Benchmarks())
+	})
+
+	serveMux.HandleFunc("/runs.json", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(h.graph.Runs())
+	})
+
+	serveMux.HandleFunc("/report.html", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Disposition", `attachment; filename="gcvis-report.html"`)
+		h.graph.WriteHTMLReport(w)
+	})
+
+	serveMux.HandleFunc("/metrics", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		h.graph.WriteMetrics(w)
+	})
+
+	serveMux.HandleFunc("/chart/", h.serveChart)
+
+	serveMux.HandleFunc("/annotations", h.serveAnnotations)
+
+	serveMux.HandleFunc("/replay", h.serveReplay)
+
+	serveMux.HandleFunc("/quit", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != "POST" {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.quitOnce.Do(func() { close(h.quit) })
+	})
+
 	server := http.Server{
 		Handler:      serveMux,
 		ReadTimeout:  10 * time.Second,
@@ -78,3 +174,99 @@ func (h *HttpServer) Listener() net.Listener {
 	h.listener = listener
 	return h.listener
 }
+
+// serveAnnotations lists the annotations, or adds one when posted with a
+// label and optionally a time in seconds, which defaults to the moment
+// it arrived.
+func (h *HttpServer) serveAnnotations(w http.ResponseWriter, req *http.Request) {
+	if req.Method == "POST" {
+		label := req.FormValue("label")
+		if strings.TrimSpace(label) == "" {
+			http.Error(w, "missing label", http.StatusBadRequest)
+			return
+		}
+
+		elapsed := h.now()
+		if t := req.FormValue("time"); t != "" {
+			var err error
+			if elapsed, err = strconv.ParseFloat(t, 64); err != nil {
+				http.Error(w, "invalid time", http.StatusBadRequest)
+				return
+			}
+		}
+
+		a := NewAnnotation(elapsed, label)
+		h.annotate(a)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(a)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	h.graph.mu.RLock()
+	defer h.graph.mu.RUnlock()
+	json.NewEncoder(w).Encode(h.graph.Annotations)
+}
+
+func (h *HttpServer) serveReplay(w http.ResponseWriter, req *http.Request) {
+	if h.replayer == nil {
+		http.NotFound(w, req)
+		return
+	}
+
+	if req.Method == "POST" {
+		switch req.FormValue("action") {
+		case "play":
+			h.replayer.Play()
+		case "pause":
+			h.replayer.Pause()
+		case "seek":
+			pos, err := strconv.ParseFloat(req.FormValue("position"), 64)
+			if err != nil {
+				http.Error(w, "invalid position", http.StatusBadRequest)
+				return
+			}
+			h.replayer.Seek(pos)
+		case "speed":
+			speed, err := strconv.ParseFloat(req.FormValue("speed"), 64)
+			if err != nil || speed <= 0 {
+				http.Error(w, "invalid speed", http.StatusBadRequest)
+				return
+			}
+			h.replayer.SetSpeed(speed)
+		default:
+			http.Error(w, "unknown action", http.StatusBadRequest)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.replayer.Status())
+}
+
+// serveChart renders /chart/<name>.svg or /chart/<name>.png, with the
+// options of parseChartOptions in the query string.
+func (h *HttpServer) serveChart(w http.ResponseWriter, req *http.Request) {
+	name := strings.TrimPrefix(req.URL.Path, "/chart/")
+	format := strings.TrimPrefix(path.Ext(name), ".")
+	name = strings.TrimSuffix(name, path.Ext(name))
+
+	if _, ok := findChartDef(name); !ok || (format != "svg" && format != "png") {
+		http.NotFound(w, req)
+		return
+	}
+
+	opts, err := parseChartOptions(req.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if format == "svg" {
+		w.Header().Set("Content-Type", "image/svg+xml")
+	} else {
+		w.Header().Set("Content-Type", "image/png")
+	}
+	h.graph.WriteChart(w, name, format, opts)
+}
@@ -5,8 +5,11 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestHttpServerListener(t *testing.T) {
@@ -87,3 +90,78 @@ func TestHttpServerJsonEndpoint(t *testing.T) {
 		t.Errorf("Expected graph to be a json string.\nExpected: %v\nGot: %v", string(result), string(body))
 	}
 }
+
+func TestHttpServerStaticFiles(t *testing.T) {
+	graph := NewGraph("fake title", GCVIS_TMPL)
+	server := NewHttpServer("127.0.0.1", "0", &graph)
+
+	go server.Start()
+	defer server.Close()
+
+	response, err := http.Get(server.Url() + "static/assets.txt")
+	if err != nil {
+		t.Fatalf("HTTP request returned an error: %v", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("Expected bundled file to be served. Got status %v.", response.Status)
+	}
+}
+
+func TestHttpServerAnnotations(t *testing.T) {
+	graph := NewGraph("fake title", GCVIS_TMPL)
+	server := NewHttpServer("127.0.0.1", "0", &graph)
+
+	go server.Start()
+	defer server.Close()
+
+	response, err := http.PostForm(server.Url()+"annotations", url.Values{"label": {"deploy v2"}, "time": {"12.5"}})
+	if err != nil {
+		t.Fatalf("HTTP request returned an error: %v", err)
+	}
+	response.Body.Close()
+
+	server.SetNow(func() float64 { return 30 })
+	response, err = http.PostForm(server.Url()+"annotations", url.Values{"label": {"now"}})
+	if err != nil {
+		t.Fatalf("HTTP request returned an error: %v", err)
+	}
+	response.Body.Close()
+
+	expected := []Annotation{{ElapsedTime: 12.5, Label: "deploy v2"}, {ElapsedTime: 30, Label: "now"}}
+	if !reflect.DeepEqual(graph.Annotations, expected) {
+		t.Errorf("Expected annotations to equal %v. Got %v instead.", expected, graph.Annotations)
+	}
+}
+
+func TestHttpServerQuit(t *testing.T) {
+	graph := NewGraph("fake title", GCVIS_TMPL)
+	server := NewHttpServer("127.0.0.1", "0", &graph)
+
+	go server.Start()
+	defer server.Close()
+
+	response, err := http.Get(server.Url() + "quit")
+	if err != nil {
+		t.Fatalf("HTTP request returned an error: %v", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("Expected GET /quit to be refused. Got status %v.", response.Status)
+	}
+
+	for i := 0; i < 2; i++ {
+		response, err = http.Post(server.Url()+"quit", "", nil)
+		if err != nil {
+			t.Fatalf("HTTP request returned an error: %v", err)
+		}
+		response.Body.Close()
+	}
+
+	select {
+	case <-server.Quit():
+	case <-time.After(100 * time.Millisecond):
+		t.Fatalf("Expected Quit to be closed.")
+	}
+}
@@ -10,9 +10,18 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/pkg/browser"
 
@@ -22,8 +31,45 @@ import (
 var iface = flag.String("i", "127.0.0.1", "specify interface to use. defaults to 127.0.0.1.")
 var port = flag.String("p", "0", "specify port to use.")
 var openBrowser = flag.Bool("o", true, "automatically open browser")
+var recordFile = flag.String("record", "", "save every input line with its arrival time to `file`")
+var replayFile = flag.String("replay", "", "replay a session saved with -record from `file`")
+var replaySpeed = flag.Float64("speed", 1, "replay speed multiplier, 0 starts the replay paused")
+var exportFile = flag.String("export", "", "write one row per GC cycle to `file` when the input ends, as .csv or .ndjson")
+var htmlReport = flag.String("html", "", "write a self-contained HTML report to `file` when the input ends")
+var chartDir = flag.String("charts", "", "render every chart as SVG and PNG into `dir` when the input ends")
+var chartOpts = flag.String("chart-opts", "", "chart `options` as a query string, e.g. width=800&height=300&from=10&to=60&series=HeapUse")
+var headless = flag.Bool("headless", false, "don't start the server, print a report when the input ends")
+var reportFormat = flag.String("report", "table", "format of the -headless report, table or json")
+var tuiMode = flag.Bool("tui", false, "show a live dashboard in the terminal instead of starting the server")
+var godebug = flag.String("godebug", DefaultGODEBUG, "runtime trace `settings` merged into the program's GODEBUG, such as gctrace=1,scavtrace=1")
+var sweepGOGC = flag.String("sweep-gogc", "", "run the program once for each of a comma separated `list` of GOGC values and compare the runs")
+var sweepMemLimit = flag.String("sweep-memlimit", "", "run the program once for each of a comma separated `list` of GOMEMLIMIT values and compare the runs")
+var duration = flag.Duration("duration", 0, "stop the program after `duration` with an interrupt and finish, or each run of a sweep")
+var benchMode = flag.Bool("bench", false, "attribute GC cycles to the benchmarks of a go test -bench command; reads the program's standard output too")
+var keepServing = flag.Bool("keep", false, "keep serving the finished session after the input ends, until interrupted or asked to quit from the page")
+var ptyMode = flag.Bool("pty", false, "run the program on a pseudo-terminal, passing its terminal output through as is")
+var compareMode = flag.Bool("compare", false, "compare sessions, given as two or more recordings or gctrace logs instead of a command")
+var markPattern = flag.String("mark", MarkRegexp, "turn output lines matching `regexp` into chart annotations, labelled by its \"label\" or first group; empty to disable")
+var baselineFile = flag.String("baseline", "", "check the run against a baseline saved in `file` when the input ends, and fail on a regression")
+var saveBaseline = flag.String("save-baseline", "", "save the run's statistics to `file` as a baseline when the input ends")
+var alerts alertRules
+var warmup Warmup
+var runCommands commandList
+var baselineTolerances = tolerances{}
+
+func init() {
+	flag.Var(&alerts, "alert", "fail when a `rule` such as pause>10 is breached; metrics are pause (ms), cpu (%), goal (MB) and rate (GCs/s); may be repeated")
+	flag.Var(&runCommands, "run", "run `command` at the same time as the others, split into arguments at spaces, and compare them live; may be repeated")
+	flag.Var(&warmup, "warmup", "leave the first `duration or cycles`, such as 30s or 100, out of statistics and alerts")
+	flag.Var(baselineTolerances, "tolerance", "override a baseline tolerance, such as p99=10% or cpu=0.5; statistics are cycles, wall, rate, interval, p50, p95, p99, pausemax, cpu, assist, goal and live; may be repeated")
+}
 
 func main() {
+	if len(os.Args) > 3 && os.Args[1] == execHelperFlag {
+		runExecHelper(os.Args[2], os.Args[3:])
+		return
+	}
+
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage of %s: command <args>...\n", os.Args[0])
 		flag.PrintDefaults()
@@ -31,9 +77,87 @@ func main() {
 
 	var pipeRead io.ReadCloser
 	var subcommand *SubCommand
+	var replayer *Replayer
+	var parser *Parser
 
 	flag.Parse()
-	if len(flag.Args()) < 1 {
+	if *exportFile != "" {
+		if _, err := exportFunc(*exportFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *htmlReport != "" {
+		if _, err := inlineScripts(); err != nil {
+			log.Fatalf("-html: %v", err)
+		}
+	}
+
+	opts, err := url.ParseQuery(*chartOpts)
+	if err != nil {
+		log.Fatalf("-chart-opts: %v", err)
+	}
+	chartOptions, err := parseChartOptions(opts)
+	if err != nil {
+		log.Fatalf("-chart-opts: %v", err)
+	}
+
+	if *reportFormat != "table" && *reportFormat != "json" {
+		log.Fatalf("unknown report format %q, use table or json", *reportFormat)
+	}
+
+	var mark *regexp.Regexp
+	if *markPattern != "" {
+		if mark, err = regexp.Compile(*markPattern); err != nil {
+			log.Fatalf("-mark: %v", err)
+		}
+	}
+
+	if *duration > 0 && (*compareMode || *replayFile != "" || (len(flag.Args()) < 1 && len(runCommands) == 0)) {
+		log.Fatal("-duration stops a command gcvis runs, it does not apply to -compare, -replay or standard input")
+	}
+
+	if *compareMode {
+		runCompare(flag.Args(), mark)
+		return
+	}
+
+	if *sweepGOGC != "" || *sweepMemLimit != "" {
+		runSweep(flag.Args(), mark)
+		return
+	}
+
+	if len(runCommands) > 0 {
+		runGroup(runCommands, flag.Args(), mark)
+		return
+	}
+
+	var baseline *Baseline
+	if *baselineFile != "" {
+		f, err := os.Open(*baselineFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		b, err := ReadBaseline(f)
+		f.Close()
+		if err != nil {
+			log.Fatalf("%s: %v", *baselineFile, err)
+		}
+		baseline = &b
+	}
+
+	if *replayFile != "" {
+		f, err := os.Open(*replayFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		replayer, err = NewReplayer(f, *replaySpeed)
+		f.Close()
+		if err != nil {
+			log.Fatalf("%s: %v", *replayFile, err)
+		}
+		parser = NewReplayParser(replayer)
+	} else if len(flag.Args()) < 1 {
 		if terminal.IsTerminal(int(os.Stdin.Fd())) {
 			flag.Usage()
 			return
@@ -42,54 +166,510 @@ func main() {
 		}
 	} else {
 		subcommand = NewSubCommand(flag.Args())
+		subcommand.SetGODEBUG(*godebug)
+		switch {
+		case *ptyMode && *tuiMode:
+			err = subcommand.UsePTY(nil)
+		case *ptyMode:
+			err = subcommand.UsePTY(os.Stdout)
+		case *tuiMode || *benchMode:
+			subcommand.CaptureStdout()
+		}
+		if err != nil {
+			log.Fatalf("-pty: %v", err)
+		}
 		pipeRead = subcommand.PipeRead
+		forwardSignals(subcommand)
+		if *duration > 0 {
+			subcommand.StopAfter(*duration, stopGrace)
+		}
 		go subcommand.Run()
 	}
 
-	parser := NewParser(pipeRead)
+	if parser == nil {
+		parser = NewParser(pipeRead)
+	}
+	parser.Mark = mark
+	parser.Benchmarks = *benchMode
+
+	if *recordFile != "" {
+		f, err := os.Create(*recordFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		parser.Recorder = NewRecorder(f)
+	}
 
 	title := strings.Join(flag.Args(), " ")
+	if *replayFile != "" {
+		title = fmt.Sprintf("replay of %s", *replayFile)
+	}
 	if len(title) == 0 {
 		title = fmt.Sprintf("%s:%s", *iface, *port)
 	}
 
 	gcvisGraph := NewGraph(title, GCVIS_TMPL)
-	server := NewHttpServer(*iface, *port, &gcvisGraph)
+	gcvisGraph.SetWarmup(warmup)
+	if subcommand != nil {
+		gcvisGraph.Env = subcommand.RuntimeEnv()
+	}
 
 	go parser.Run()
-	go server.Start()
 
-	url := server.Url()
+	var server *HttpServer
+	if !*headless && !*tuiMode {
+		server = NewHttpServer(*iface, *port, &gcvisGraph)
+		if replayer != nil {
+			server.SetReplayer(replayer)
+		}
+		server.SetAnnotate(parser.Annotate)
+		server.SetNow(parser.Now)
+
+		go server.Start()
 
-	if *openBrowser {
-		log.Printf("opening browser window, if this fails, navigate to %s", url)
-		browser.OpenURL(url)
-	} else {
-		log.Printf("server started on %s", url)
+		url := server.Url()
+
+		if *openBrowser {
+			log.Printf("opening browser window, if this fails, navigate to %s", url)
+			browser.OpenURL(url)
+		} else {
+			log.Printf("server started on %s", url)
+		}
+	}
+
+	alerter := NewAlerter(alerts)
+	alerter.Warmup = warmup
+
+	// output that is not traces is passed on, except on a pty, where the
+	// program's output already went through as is. go test writes its
+	// output, benchmarks' included, to its standard output.
+	var echo io.Writer = os.Stderr
+	switch {
+	case subcommand != nil && *ptyMode:
+		echo = ioutil.Discard
+	case *benchMode, subcommand != nil && isGoTest(subcommand.args) && !*tuiMode:
+		echo = os.Stdout
+	}
+
+	var tui *TUI
+	var redraw <-chan time.Time
+	if *tuiMode {
+		tui = NewTUI(&gcvisGraph)
+		redraw = time.Tick(500 * time.Millisecond)
+	}
+
+	// a replay that is kept on the page can still be moved once it has
+	// ended, so it is held at the end rather than closed
+	var finished <-chan bool = parser.done
+	if replayer != nil && *keepServing && server != nil {
+		replayer.Hold = true
+		finished = replayer.Ended()
 	}
 
 	for {
 		select {
 		case gcTrace := <-parser.GcChan:
 			gcvisGraph.AddGCTraceGraphPoint(gcTrace)
+			for _, breach := range alerter.Check(gcTrace) {
+				log.Print(breach)
+				gcvisGraph.AddBreach(breach)
+			}
 		case scvgTrace := <-parser.ScvgChan:
 			gcvisGraph.AddScavengerGraphPoint(scvgTrace)
 		case output := <-parser.NoMatchChan:
-			fmt.Fprintln(os.Stderr, output)
-		case <-parser.done:
+			if tui != nil {
+				tui.AddOutput(output)
+			} else {
+				fmt.Fprintln(echo, output)
+			}
+		case <-redraw:
+			drawTUI(tui)
+		case annotation := <-parser.AnnotationChan:
+			gcvisGraph.AddAnnotation(annotation)
+		case <-parser.ResetChan:
+			gcvisGraph.Reset()
+			alerter.Reset()
+		case <-finished:
+			if parser.pending() {
+				// take the last lines before finishing
+				continue
+			}
+
+			ended := "input ended"
+			if subcommand != nil {
+				ended = subcommand.ExitMessage()
+			}
+			gcvisGraph.SetEnded(ended)
+
+			if tui != nil {
+				drawTUI(tui)
+			}
+
+			if *exportFile != "" {
+				if err := writeExport(&gcvisGraph, *exportFile); err != nil {
+					log.Print(err)
+				}
+			}
+
+			if *chartDir != "" {
+				if err := writeCharts(&gcvisGraph, *chartDir, chartOptions); err != nil {
+					log.Print(err)
+				}
+			}
+
+			if *htmlReport != "" {
+				if err := writeHTMLReport(&gcvisGraph, *htmlReport); err != nil {
+					log.Print(err)
+				}
+			}
+
+			if *headless {
+				writeReport(gcvisGraph.Report(), *reportFormat)
+			}
+
+			if *saveBaseline != "" {
+				if err := writeBaseline(NewBaseline(gcvisGraph.Report()), *saveBaseline); err != nil {
+					log.Print(err)
+				}
+			}
+
+			regressions := 0
+			if baseline != nil {
+				checks := baseline.Check(gcvisGraph.Report(), baselineTolerances)
+				if regressions, err = writeBaselineChecks(os.Stdout, baseline.Title, checks); err != nil {
+					log.Print(err)
+				}
+			}
+
+			if *keepServing && server != nil {
+				log.Printf("%s, still serving on %s; press Ctrl-C to exit", ended, server.Url())
+				keepServingUntilQuit(server, parser, &gcvisGraph)
+			}
+
 			if parser.Err != nil {
 				fmt.Fprintf(os.Stderr, parser.Err.Error())
 				os.Exit(1)
 			}
 
-			os.Exit(0)
+			status := 0
+			if subcommand != nil {
+				status = subcommand.ExitCode()
+			}
+			if status != 0 {
+				log.Print(ended)
+			}
+
+			if len(alerter.Breaches) > 0 {
+				log.Printf("%d alert(s) breached", len(alerter.Breaches))
+				if status == 0 {
+					status = 1
+				}
+			}
+
+			if regressions > 0 {
+				log.Printf("%d statistic(s) regressed from the baseline", regressions)
+				if status == 0 {
+					status = 1
+				}
+			}
+
+			os.Exit(status)
 		}
 	}
+}
+
+// forwardSignals relays the signals that would stop gcvis to the command
+// while it runs, so that gcvis ends when the command does, with its
+// status. The command runs in a process group of its own. If it has the
+// terminal, keys such as ^C reach it and not gcvis; otherwise they reach
+// gcvis alone. Either way the command gets each signal once.
+func forwardSignals(s *SubCommand) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(signals)
+		for {
+			select {
+			case sig := <-signals:
+				if err := s.Signal(sig); err != nil {
+					log.Printf("forwarding %v: %v", sig, err)
+				}
+			case <-s.Done():
+				return
+			}
+		}
+	}()
+}
 
-	if subcommand != nil && subcommand.Err() != nil {
-		fmt.Fprintf(os.Stderr, subcommand.Err().Error())
-		os.Exit(1)
+// keepServingUntilQuit waits for an interrupt or for the page to ask
+// gcvis to quit, while still taking annotations posted to the page, and
+// the lines of a replay moved back on it.
+func keepServingUntilQuit(server *HttpServer, parser *Parser, g *Graph) {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(interrupt)
+
+	for {
+		select {
+		case gcTrace := <-parser.GcChan:
+			g.AddGCTraceGraphPoint(gcTrace)
+		case scvgTrace := <-parser.ScvgChan:
+			g.AddScavengerGraphPoint(scvgTrace)
+		case <-parser.NoMatchChan:
+			// passed on the first time through
+		case <-parser.ResetChan:
+			g.Reset()
+		case annotation := <-parser.AnnotationChan:
+			g.AddAnnotation(annotation)
+		case <-server.Quit():
+			return
+		case <-interrupt:
+			return
+		}
+	}
+}
+
+func writeExport(g *Graph, filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	write, err := exportFunc(filename)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := write(g, f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+func drawTUI(tui *TUI) {
+	width, height, err := terminal.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		width, height = 80, 24
+	}
+	tui.Draw(os.Stdout, width, height)
+}
+
+func writeCharts(g *Graph, dir string, opts chartOptions) error {
+	for _, def := range chartDefs {
+		for _, format := range []string{"svg", "png"} {
+			f, err := os.Create(filepath.Join(dir, def.Name+"."+format))
+			if err != nil {
+				return err
+			}
+
+			if err := g.WriteChart(f, def.Name, format, opts); err != nil {
+				f.Close()
+				return err
+			}
+
+			if err := f.Close(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func writeHTMLReport(r interface{ WriteHTMLReport(io.Writer) error }, filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	if err := r.WriteHTMLReport(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+func writeBaseline(b Baseline, filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	if err := b.WriteJSON(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+func writeReport(r Report, format string) {
+	var err error
+	if format == "json" {
+		err = r.WriteJSON(os.Stdout)
+	} else {
+		err = r.WriteTable(os.Stdout)
+	}
+
+	if err != nil {
+		log.Print(err)
+	}
+}
+
+func runCompare(files []string, mark *regexp.Regexp) {
+	refuseSingleSessionFlags("-compare")
+	if len(files) < 2 {
+		log.Fatal("-compare needs at least two recordings or logs")
+	}
+
+	var sessions []*Graph
+	for _, file := range files {
+		session, err := LoadSession(file, mark)
+		if err != nil {
+			log.Fatal(err)
+		}
+		session.SetWarmup(warmup)
+		sessions = append(sessions, session)
+	}
+
+	showComparison(NewComparison(sessions...))
+}
+
+func runSweep(args []string, mark *regexp.Regexp) {
+	refuseSingleSessionFlags("a sweep")
+	if len(args) < 1 {
+		log.Fatal("a sweep needs a command to run")
+	}
+
+	sweep := &Sweep{
+		Args:     args,
+		Settings: sweepSettings(splitList(*sweepGOGC), splitList(*sweepMemLimit)),
+		GODEBUG:  *godebug,
+		Duration: *duration,
+		Mark:     mark,
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	sweep.Signals = signals
+	sessions, err := sweep.Run()
+	signal.Stop(signals)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, session := range sessions {
+		session.SetWarmup(warmup)
+	}
+
+	showComparison(NewComparison(sessions...))
+}
+
+// runGroup runs the commands of -run, and the one given as arguments if
+// any, at the same time. Their sessions are compared live until they
+// have all finished, and then as a sweep's are, except that the page is
+// only kept with -keep.
+func runGroup(commands [][]string, args []string, mark *regexp.Regexp) {
+	refuseSingleSessionFlags("-run")
+
+	if len(args) > 0 {
+		commands = append(commands, args)
+	}
+
+	group := &Group{
+		Commands: commands,
+		GODEBUG:  *godebug,
+		Duration: *duration,
+		Mark:     mark,
+	}
+	sessions := group.Start(os.Stdout, os.Stderr)
+	for _, session := range sessions {
+		session.SetWarmup(warmup)
+	}
+	comparison := NewComparison(sessions...)
+	comparison.Live = true
+
+	var served <-chan error
+	if !*headless {
+		served = serveComparison(comparison)
+	}
+
+	status := group.Wait()
+	writeComparison(comparison)
+
+	if *keepServing && served != nil {
+		log.Print("every command has ended, still serving; press Ctrl-C to exit")
+		log.Fatal(<-served)
+	}
+	os.Exit(status)
+}
+
+// singleSessionFlags act on a single session, and are not carried out
+// for the sessions of a comparison.
+var singleSessionFlags = map[string]bool{
+	"alert": true, "baseline": true, "save-baseline": true,
+	"record": true, "replay": true, "speed": true,
+	"export": true, "charts": true, "chart-opts": true,
+	"tui": true, "pty": true, "bench": true,
+}
+
+// refuseSingleSessionFlags ends gcvis if a flag that acts on a single
+// session was given along with mode, rather than silently leave it out.
+func refuseSingleSessionFlags(mode string) {
+	flag.Visit(func(f *flag.Flag) {
+		if singleSessionFlags[f.Name] || (f.Name == "report" && *reportFormat != "table") {
+			log.Fatalf("-%s does not apply to %s", f.Name, mode)
+		}
+	})
+}
+
+// showComparison prints the comparison table, writes the HTML report if
+// asked to, and serves the comparison page until interrupted.
+func showComparison(comparison *Comparison) {
+	writeComparison(comparison)
+
+	if *headless {
+		return
+	}
+	log.Fatal(<-serveComparison(comparison))
+}
+
+// writeComparison prints the comparison table and writes the HTML report
+// if asked to.
+func writeComparison(comparison *Comparison) {
+	if err := comparison.WriteTable(os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+
+	if *htmlReport != "" {
+		if err := writeHTMLReport(comparison, *htmlReport); err != nil {
+			log.Fatalf("%s: %v", *htmlReport, err)
+		}
+	}
+}
+
+// serveComparison serves the comparison page in the background, and
+// returns the error that ends serving it.
+func serveComparison(comparison *Comparison) <-chan error {
+	listener, err := net.Listen("tcp4", fmt.Sprintf("%v:%v", *iface, *port))
+	if err != nil {
+		log.Fatal(err)
+	}
+	url := fmt.Sprintf("http://%s/", listener.Addr())
+	if *openBrowser {
+		log.Printf("opening browser window, if this fails, navigate to %s", url)
+		browser.OpenURL(url)
+	} else {
+		log.Printf("server started on %s", url)
 	}
 
-	os.Exit(0)
+	served := make(chan error, 1)
+	go func() {
+		served <- http.Serve(listener, comparison.Handler())
+	}()
+	return served
 }
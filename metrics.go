@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const bytesPerMB = 1 << 20
+
+// pauseBuckets are the upper bounds, in seconds, of the pause histogram.
+var pauseBuckets = []float64{0.00001, 0.00005, 0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// pauseMs is the total stop-the-world time of a cycle, in milliseconds.
+func pauseMs(t *gctrace) float64 {
+	return t.STWSclock + t.STWMclock
+}
+
+// WriteMetrics writes the latest and cumulative GC statistics in the
+// Prometheus text exposition format, labelled with the graph title.
+func (g *Graph) WriteMetrics(w io.Writer) error {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	bw := bufio.NewWriter(w)
+	label := fmt.Sprintf(`session="%s"`, labelEscaper.Replace(g.Title))
+
+	metric := func(name, kind, help string, value float64) {
+		fmt.Fprintf(bw, "# HELP %s %s\n", name, help)
+		fmt.Fprintf(bw, "# TYPE %s %s\n", name, kind)
+		fmt.Fprintf(bw, "%s{%s} %s\n", name, label, formatFloat(value))
+	}
+
+	var assistMs, pauseSum float64
+	counts := make([]int, len(pauseBuckets))
+	for _, trace := range g.gcTraces {
+		assistMs += trace.MASAssistcpu
+		pause := pauseMs(trace) / 1000
+		pauseSum += pause
+		for i, le := range pauseBuckets {
+			if pause <= le {
+				counts[i]++
+			}
+		}
+	}
+
+	metric("gcvis_gc_cycles_total", "counter", "Number of GC cycles seen.", float64(len(g.gcTraces)))
+
+	fmt.Fprintf(bw, "# HELP gcvis_gc_pause_seconds Stop-the-world pause time per GC cycle.\n")
+	fmt.Fprintf(bw, "# TYPE gcvis_gc_pause_seconds histogram\n")
+	for i, le := range pauseBuckets {
+		fmt.Fprintf(bw, "gcvis_gc_pause_seconds_bucket{%s,le=\"%s\"} %d\n", label, formatFloat(le), counts[i])
+	}
+	fmt.Fprintf(bw, "gcvis_gc_pause_seconds_bucket{%s,le=\"+Inf\"} %d\n", label, len(g.gcTraces))
+	fmt.Fprintf(bw, "gcvis_gc_pause_seconds_sum{%s} %s\n", label, formatFloat(pauseSum))
+	fmt.Fprintf(bw, "gcvis_gc_pause_seconds_count{%s} %d\n", label, len(g.gcTraces))
+
+	metric("gcvis_gc_assist_cpu_seconds_total", "counter", "CPU time spent in mark assists.", assistMs/1000)
+
+	if n := len(g.gcTraces); n > 0 {
+		last := g.gcTraces[n-1]
+		metric("gcvis_heap_goal_bytes", "gauge", "Heap goal of the latest GC cycle.", float64(last.Heap1*bytesPerMB))
+		metric("gcvis_heap_live_bytes", "gauge", "Live heap after the latest GC cycle.", float64(last.HeapLive*bytesPerMB))
+	}
+
+	if n := len(g.scvgTraces); n > 0 {
+		last := g.scvgTraces[n-1]
+		metric("gcvis_scvg_released_bytes", "gauge", "Memory returned to the operating system by the scavenger.", float64(last.released*bytesPerMB))
+	}
+
+	return bw.Flush()
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestGraphWriteMetrics(t *testing.T) {
+	graph := NewGraph(`say "hi"`, GCVIS_TMPL)
+	graph.AddGCTraceGraphPoint(&gctrace{ElapsedTime: 1, Heap1: 4, HeapLive: 2, STWSclock: 0.2, STWMclock: 0.1, MASAssistcpu: 3})
+	graph.AddGCTraceGraphPoint(&gctrace{ElapsedTime: 2, Heap1: 8, HeapLive: 3, STWSclock: 20, MASAssistcpu: 1})
+	graph.AddScavengerGraphPoint(&scvgtrace{ElapsedTime: 2, released: 1})
+
+	w := &bytes.Buffer{}
+	if err := graph.WriteMetrics(w); err != nil {
+		t.Fatalf("WriteMetrics returned an error: %v", err)
+	}
+
+	for _, expected := range []string{
+		`gcvis_gc_cycles_total{session="say \"hi\""} 2`,
+		`gcvis_gc_pause_seconds_bucket{session="say \"hi\"",le="0.0005"} 1`,
+		`gcvis_gc_pause_seconds_bucket{session="say \"hi\"",le="0.05"} 2`,
+		`gcvis_gc_pause_seconds_count{session="say \"hi\""} 2`,
+		`gcvis_gc_assist_cpu_seconds_total{session="say \"hi\""} 0.004`,
+		`gcvis_heap_goal_bytes{session="say \"hi\""} 8388608`,
+		`gcvis_heap_live_bytes{session="say \"hi\""} 3145728`,
+		`gcvis_scvg_released_bytes{session="say \"hi\""} 1048576`,
+	} {
+		if !strings.Contains(w.String(), expected+"\n") {
+			t.Errorf("Expected metrics to contain %q. Got:\n%v", expected, w.String())
+		}
+	}
+}
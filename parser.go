@@ -5,12 +5,18 @@ import (
 	"io"
 	"regexp"
 	"strconv"
+	"sync"
+	"time"
 )
 
 const (
-	GCRegexpGo14 = `gc\d+\(\d+\): ([\d.]+\+?)+ us, \d+ -> (?P<Heap1>\d+) MB, \d+ \(\d+-\d+\) objects,( \d+ goroutines,)? \d+\/\d+\/\d+ sweeps, \d+\(\d+\) handoff, \d+\(\d+\) steal, \d+\/\d+\/\d+ yields`
-	GCRegexpGo15 = `gc #?\d+ @(?P<ElapsedTime>[\d.]+)s \d+%: [\d.+/]+ ms clock, [\d.+/]+ ms cpu, \d+->\d+->\d+ MB, (?P<Heap1>\d+) MB goal, \d+ P`
-	GCRegexpGo16 = `gc #?\d+ @(?P<ElapsedTime>[\d.]+)s \d+%: (?P<STWSclock>[^+]+)\+(?P<MASclock>[^+]+)\+(?P<STWMclock>[^+]+) ms clock, (?P<STWScpu>[^+]+)\+(?P<MASAssistcpu>[^+]+)/(?P<MASBGcpu>[^+]+)/(?P<MASIdlecpu>[^+]+)\+(?P<STWMcpu>[^+]+) ms cpu, \d+->\d+->\d+ MB, (?P<Heap1>\d+) MB goal, \d+ P`
+	GCRegexpGo14 = `gc(?P<NumGC>\d+)\(\d+\): ([\d.]+\+?)+ us, \d+ -> (?P<Heap1>\d+) MB, \d+ \(\d+-\d+\) objects,( \d+ goroutines,)? \d+\/\d+\/\d+ sweeps, \d+\(\d+\) handoff, \d+\(\d+\) steal, \d+\/\d+\/\d+ yields`
+	GCRegexpGo15 = `gc #?(?P<NumGC>\d+) @(?P<ElapsedTime>[\d.]+)s (?P<CPUPercent>\d+)%: [\d.+/]+ ms clock, [\d.+/]+ ms cpu, (?P<Heap0>\d+)->\d+->(?P<HeapLive>\d+) MB, (?P<Heap1>\d+) MB goal, (?P<Nproc>\d+) P`
+	GCRegexpGo16 = `gc #?(?P<NumGC>\d+) @(?P<ElapsedTime>[\d.]+)s (?P<CPUPercent>\d+)%: (?P<STWSclock>[^+]+)\+(?P<MASclock>[^+]+)\+(?P<STWMclock>[^+]+) ms clock, (?P<STWScpu>[^+]+)\+(?P<MASAssistcpu>[^+]+)/(?P<MASBGcpu>[^+]+)/(?P<MASIdlecpu>[^+]+)\+(?P<STWMcpu>[^+]+) ms cpu, (?P<Heap0>\d+)->\d+->(?P<HeapLive>\d+) MB, (?P<Heap1>\d+) MB goal, (?:\d+ MB stacks, )?(?:\d+ MB globals, )?(?P<Nproc>\d+) P`
+
+	// MarkRegexp matches lines the traced program prints to mark an
+	// event on the charts, labelled with the rest of the line.
+	MarkRegexp = `^GCVIS_MARK\s+(?P<label>.*)`
 
 	SCVGRegexp = `scvg\d+: inuse: (?P<inuse>\d+), idle: (?P<idle>\d+), sys: (?P<sys>\d+), released: (?P<released>\d+), consumed: (?P<consumed>\d+) \(MB\)`
 )
@@ -22,67 +28,212 @@ var (
 	scvgre   = regexp.MustCompile(SCVGRegexp)
 )
 
+// An inputLine is a raw input line, or an annotation, together with the
+// time it arrived in seconds since the start of the session.
+type inputLine struct {
+	Text       string
+	Elapsed    float64
+	Annotation bool
+}
+
+// A lineSource yields input lines in the order they arrived.
+//
+// Next returns io.EOF once the input is exhausted, and errRewind when
+// the source has started over and everything seen so far is stale.
+type lineSource interface {
+	Next() (inputLine, error)
+}
+
+// A readerSource stamps each line with the time it was read, in seconds
+// since start.
+type readerSource struct {
+	sc    *bufio.Scanner
+	start time.Time
+}
+
+func (s *readerSource) Next() (inputLine, error) {
+	if !s.sc.Scan() {
+		if err := s.sc.Err(); err != nil {
+			return inputLine{}, err
+		}
+		return inputLine{}, io.EOF
+	}
+
+	return inputLine{Text: s.sc.Text(), Elapsed: time.Now().Sub(s.start).Seconds()}, nil
+}
+
 type Parser struct {
-	reader      io.Reader
-	GcChan      chan *gctrace
-	ScvgChan    chan *scvgtrace
-	NoMatchChan chan string
-	done        chan bool
+	source         lineSource
+	GcChan         chan *gctrace
+	ScvgChan       chan *scvgtrace
+	NoMatchChan    chan string
+	AnnotationChan chan Annotation
+	ResetChan      chan bool
+	done           chan bool
 
-	Err error
+	// Recorder, if set, receives every raw input line and annotation.
+	Recorder *Recorder
 
-	scvgRegexp *regexp.Regexp
+	// Mark, if set, turns the lines it matches into annotations instead
+	// of passing them on as unmatched output. The label is taken from a
+	// group named "label", the first group, or else the whole match.
+	Mark *regexp.Regexp
+
+	// Benchmarks, if set, attributes GC cycles to the benchmarks of
+	// go test -bench, whose output must be part of the input.
+	Benchmarks bool
+	benchmark  string
+	benchName  string // the start of the running benchmark's result line
+
+	runs   runTracker
+	skewMu sync.Mutex // guards runs.skew, which Now reads
+
+	Err error
 }
 
 func NewParser(r io.Reader) *Parser {
+	return newParser(&readerSource{sc: bufio.NewScanner(r), start: StartTime})
+}
+
+// NewReplayParser returns a Parser fed from a recording, with every
+// trace stamped with the time it was originally received.
+func NewReplayParser(r *Replayer) *Parser {
+	return newParser(r)
+}
+
+func newParser(source lineSource) *Parser {
 	return &Parser{
-		reader:      r,
-		GcChan:      make(chan *gctrace, 1),
-		ScvgChan:    make(chan *scvgtrace, 1),
-		NoMatchChan: make(chan string, 1),
-		done:        make(chan bool),
+		source:         source,
+		GcChan:         make(chan *gctrace, 1),
+		ScvgChan:       make(chan *scvgtrace, 1),
+		NoMatchChan:    make(chan string, 1),
+		AnnotationChan: make(chan Annotation, 1),
+		ResetChan:      make(chan bool),
+		done:           make(chan bool),
 	}
 }
 
-func (p *Parser) Run() {
-	sc := bufio.NewScanner(p.reader)
+// Annotate records an annotation and passes it on along with the
+// traces. It is safe to call while the Parser runs.
+func (p *Parser) Annotate(a Annotation) {
+	if p.Recorder != nil {
+		p.Recorder.RecordAnnotation(a)
+	}
+	p.AnnotationChan <- a
+}
+
+// pending reports whether parsed lines are still waiting to be taken,
+// which they may be after done is closed.
+func (p *Parser) pending() bool {
+	return len(p.GcChan) > 0 || len(p.ScvgChan) > 0 || len(p.NoMatchChan) > 0 || len(p.AnnotationChan) > 0
+}
 
-	for sc.Scan() {
-		line := sc.Text()
-		if result := gcrego16.FindStringSubmatch(line); result != nil {
-			p.GcChan <- parseGCTrace(gcrego16, result)
+func (p *Parser) Run() {
+	for {
+		in, err := p.source.Next()
+		if err == errRewind {
+			p.skewMu.Lock()
+			p.runs = runTracker{}
+			p.skewMu.Unlock()
+			p.ResetChan <- true
 			continue
 		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			p.Err = err
+			break
+		}
 
-		if result := gcrego15.FindStringSubmatch(line); result != nil {
-			p.GcChan <- parseGCTrace(gcrego15, result)
+		if in.Annotation {
+			p.Annotate(Annotation{ElapsedTime: in.Elapsed, Label: in.Text})
 			continue
 		}
 
-		if result := gcrego14.FindStringSubmatch(line); result != nil {
-			p.GcChan <- parseGCTrace(gcrego14, result)
-			continue
+		if p.Recorder != nil {
+			p.Recorder.Record(in.Elapsed, in.Text)
 		}
 
-		if result := scvgre.FindStringSubmatch(line); result != nil {
-			p.ScvgChan <- parseSCVGTrace(result)
-			continue
+		p.parseLine(in.Text, in.Elapsed)
+	}
+
+	close(p.done)
+}
+
+func (p *Parser) parseLine(line string, elapsed float64) {
+	if p.Benchmarks {
+		var ok bool
+		if line, ok = p.trackBenchmark(line, elapsed); !ok {
+			return
 		}
+	}
 
-		p.NoMatchChan <- line
+	if result := gcrego16.FindStringSubmatch(line); result != nil {
+		p.GcChan <- p.stamp(parseGCTrace(gcrego16, result), elapsed, true)
+		return
 	}
 
-	p.Err = sc.Err()
+	if result := gcrego15.FindStringSubmatch(line); result != nil {
+		p.GcChan <- p.stamp(parseGCTrace(gcrego15, result), elapsed, true)
+		return
+	}
 
-	close(p.done)
+	if result := gcrego14.FindStringSubmatch(line); result != nil {
+		p.GcChan <- p.stamp(parseGCTrace(gcrego14, result), elapsed, false)
+		return
+	}
+
+	if result := scvgre.FindStringSubmatch(line); result != nil {
+		scvg := parseSCVGTrace(result)
+		scvg.ElapsedTime = p.place(elapsed)
+		p.ScvgChan <- scvg
+		return
+	}
+
+	if p.Mark != nil {
+		if result := p.Mark.FindStringSubmatch(line); result != nil {
+			p.AnnotationChan <- NewAnnotation(p.place(elapsed), markLabel(p.Mark, result))
+			return
+		}
+	}
+
+	p.NoMatchChan <- line
+}
+
+// stamp places a trace on the timeline and tags it with its run and the
+// running benchmark. Traces that are not timed, those from Go 1.4, are
+// placed at the time the line arrived.
+func (p *Parser) stamp(gc *gctrace, elapsed float64, timed bool) *gctrace {
+	if !timed {
+		gc.ElapsedTime = elapsed
+	}
+	p.trackRun(gc, elapsed, timed)
+	gc.Benchmark = p.benchmark
+	return gc
+}
+
+func markLabel(re *regexp.Regexp, matches []string) string {
+	if i := re.SubexpIndex("label"); i > 0 {
+		return matches[i]
+	}
+	if len(matches) > 1 {
+		return matches[1]
+	}
+	return matches[0]
 }
 
 func parseGCTrace(gcre *regexp.Regexp, matches []string) *gctrace {
 	matchMap := getMatchMap(gcre, matches)
 
 	return &gctrace{
+		NumGC:        silentParseInt(matchMap["NumGC"]),
+		Heap0:        silentParseInt(matchMap["Heap0"]),
 		Heap1:        silentParseInt(matchMap["Heap1"]),
+		HeapLive:     silentParseInt(matchMap["HeapLive"]),
+		Nproc:        silentParseInt(matchMap["Nproc"]),
 		ElapsedTime:  silentParseFloat(matchMap["ElapsedTime"]),
+		CPUPercent:   silentParseInt(matchMap["CPUPercent"]),
 		STWSclock:    silentParseFloat(matchMap["STWSclock"]),
 		MASclock:     silentParseFloat(matchMap["MASclock"]),
 		STWMclock:    silentParseFloat(matchMap["STWMclock"]),
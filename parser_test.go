@@ -2,13 +2,31 @@ package main
 
 import (
 	"bytes"
+	"fmt"
 	"reflect"
+	"regexp"
 	"testing"
 	"time"
 )
 
 var parser *Parser
 
+// gcLine returns a Go 1.16 trace line of cycle n at time at, with stop
+// the world phases of stw ms, and the heap at start, end and live, and
+// its goal, in MB.
+func gcLine(n int, at, stw float64, start, end, live, goal int) string {
+	return fmt.Sprintf("gc %d @%.3fs 1%%: %g+1+%g ms clock, 0.1+1/1/1+0.1 ms cpu, %d->%d->%d MB, %d MB goal, 8 P", n, at, stw, stw, start, end, live, goal)
+}
+
+// annotationLabels returns the labels of the annotations on graph.
+func annotationLabels(graph *Graph) []string {
+	var labels []string
+	for _, a := range graph.Annotations {
+		labels = append(labels, a.Label)
+	}
+	return labels
+}
+
 func runParserWith(line string) *Parser {
 	reader := bytes.NewReader([]byte(line))
 	parser = NewParser(reader)
@@ -22,8 +40,14 @@ func TestParserWithMatchingInputGo16(t *testing.T) {
 	runParserWith(line)
 
 	expectedGCTrace := &gctrace{
+		NumGC:        763,
+		Run:          1,
+		Heap0:        6370,
 		Heap1:        6533,
+		HeapLive:     3298,
+		Nproc:        8,
 		ElapsedTime:  77536.239,
+		CPUPercent:   1,
 		STWSclock:    0.11,
 		MASclock:     2192,
 		STWMclock:    0.75,
@@ -44,14 +68,54 @@ func TestParserWithMatchingInputGo16(t *testing.T) {
 	}
 }
 
+func TestParserWithMatchingInputGo119(t *testing.T) {
+	line := "gc 12 @1.402s 3%: 0.021+1.8+0.040 ms clock, 0.16+0.35/3.1/0.72+0.32 ms cpu, 7->8->2 MB, 9 MB goal, 0 MB stacks, 1 MB globals, 8 P"
+
+	runParserWith(line)
+
+	expectedGCTrace := &gctrace{
+		NumGC:        12,
+		Run:          1,
+		Heap0:        7,
+		Heap1:        9,
+		HeapLive:     2,
+		Nproc:        8,
+		ElapsedTime:  1.402,
+		CPUPercent:   3,
+		STWSclock:    0.021,
+		MASclock:     1.8,
+		STWMclock:    0.040,
+		STWScpu:      0.16,
+		MASAssistcpu: 0.35,
+		MASBGcpu:     3.1,
+		MASIdlecpu:   0.72,
+		STWMcpu:      0.32,
+	}
+
+	select {
+	case gctrace := <-parser.GcChan:
+		if !reflect.DeepEqual(gctrace, expectedGCTrace) {
+			t.Errorf("Expected gctrace to equal %+v. Got %+v instead.", expectedGCTrace, gctrace)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatalf("Execution timed out.")
+	}
+}
+
 func TestParserWithMatchingInputGo15(t *testing.T) {
 	line := "gc 88 @3.243s 9%: 0.040+16+1.0+5.9+0.34 ms clock, 0.16+16+0+18/5.7/11+1.3 ms cpu, 32->33->19 MB, 33 MB goal, 4 P"
 
 	runParserWith(line)
 
 	expectedGCTrace := &gctrace{
+		NumGC:       88,
+		Run:         1,
+		Heap0:       32,
 		Heap1:       33,
+		HeapLive:    19,
+		Nproc:       4,
 		ElapsedTime: 3.243,
+		CPUPercent:  9,
 	}
 
 	select {
@@ -70,11 +134,18 @@ func TestParserWithMatchingInputGo14(t *testing.T) {
 	runParserWith(line)
 
 	expectedGCTrace := &gctrace{
+		NumGC: 76,
+		Run:   1,
 		Heap1: 3,
 	}
 
 	select {
 	case gctrace := <-parser.GcChan:
+		if gctrace.ElapsedTime <= 0 {
+			t.Errorf("Expected gctrace to be stamped with its arrival time. Got %v.", gctrace.ElapsedTime)
+		}
+		expectedGCTrace.ElapsedTime = gctrace.ElapsedTime
+
 		if !reflect.DeepEqual(gctrace, expectedGCTrace) {
 			t.Errorf("Expected gctrace to equal %+v. Got %+v instead.", expectedGCTrace, gctrace)
 		}
@@ -89,11 +160,18 @@ func TestParserGoRoutinesInputGo14(t *testing.T) {
 	runParserWith(line)
 
 	expectedGCTrace := &gctrace{
+		NumGC: 76,
+		Run:   1,
 		Heap1: 3,
 	}
 
 	select {
 	case gctrace := <-parser.GcChan:
+		if gctrace.ElapsedTime <= 0 {
+			t.Errorf("Expected gctrace to be stamped with its arrival time. Got %v.", gctrace.ElapsedTime)
+		}
+		expectedGCTrace.ElapsedTime = gctrace.ElapsedTime
+
 		if !reflect.DeepEqual(gctrace, expectedGCTrace) {
 			t.Errorf("Expected gctrace to equal %+v. Got %+v instead.", expectedGCTrace, gctrace)
 		}
@@ -117,6 +195,11 @@ func TestParserWithScvgLine(t *testing.T) {
 
 	select {
 	case scvgTrace := <-parser.ScvgChan:
+		if scvgTrace.ElapsedTime <= 0 {
+			t.Errorf("Expected scvgTrace to be stamped with its arrival time. Got %v.", scvgTrace.ElapsedTime)
+		}
+		expectedScvgTrace.ElapsedTime = scvgTrace.ElapsedTime
+
 		if !reflect.DeepEqual(scvgTrace, expectedScvgTrace) {
 			t.Errorf("Expected scvgTrace to equal %+v. Got %+v instead.", expectedScvgTrace, scvgTrace)
 		}
@@ -142,6 +225,35 @@ func TestParserNonMatchingInput(t *testing.T) {
 	}
 }
 
+func TestParserMarkLines(t *testing.T) {
+	cases := []struct {
+		pattern  string
+		line     string
+		expected string
+	}{
+		{MarkRegexp, "GCVIS_MARK phase 2 start", "phase 2 start"},
+		{`^--- (\w+)`, "--- warmup done", "warmup"},
+		{`^=+ done =+$`, "=== done ===", "=== done ==="},
+	}
+
+	for _, c := range cases {
+		parser := NewParser(bytes.NewReader([]byte(c.line)))
+		parser.Mark = regexp.MustCompile(c.pattern)
+		go parser.Run()
+
+		select {
+		case a := <-parser.AnnotationChan:
+			if a.Label != c.expected {
+				t.Errorf("Expected label to equal %q. Got %q instead.", c.expected, a.Label)
+			}
+		case <-parser.NoMatchChan:
+			t.Errorf("Expected %q to be turned into an annotation.", c.line)
+		case <-time.After(100 * time.Millisecond):
+			t.Fatalf("Execution timed out.")
+		}
+	}
+}
+
 func TestParserWait(t *testing.T) {
 	line := "INFO: wait"
 	parser := runParserWith(line)
@@ -0,0 +1,112 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// ownProcessGroup makes cmd start in a process group of its own, so that
+// it can be signalled together with every process it starts, such as the
+// program go run builds.
+//
+// When gcvis runs in the foreground of the terminal that is the
+// command's standard input, the command's group takes its place there,
+// so that the command can read the terminal and keys such as ^C, ^Z and
+// ^\ reach it as they would without gcvis. The terminal is returned, and
+// ok is true.
+func ownProcessGroup(cmd *exec.Cmd) (tty int, ok bool) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+
+	in, isFile := cmd.Stdin.(*os.File)
+	if !isFile || !terminal.IsTerminal(int(in.Fd())) {
+		return 0, false
+	}
+	tty = int(in.Fd())
+	if pgrp, err := foregroundGroup(tty); err != nil || pgrp != syscall.Getpgrp() {
+		return 0, false
+	}
+	cmd.SysProcAttr.Foreground = true
+	cmd.SysProcAttr.Ctty = tty
+	return tty, true
+}
+
+// signalGroup sends sig to the process group that p leads.
+func signalGroup(p *os.Process, sig os.Signal) error {
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		return p.Signal(sig)
+	}
+	return syscall.Kill(-p.Pid, s)
+}
+
+// followStops keeps gcvis in step with a command that has the terminal
+// tty until done is closed. When ^Z stops the command, gcvis takes the
+// terminal back and stops along with the rest of its job, so that the
+// shell gets the terminal; once the job is continued, the command gets
+// the terminal again, if gcvis is in the foreground, and is continued.
+func followStops(p *os.Process, tty int, done <-chan bool) {
+	children := make(chan os.Signal, 1)
+	signal.Notify(children, syscall.SIGCHLD)
+	defer signal.Stop(children)
+
+	for {
+		select {
+		case <-children:
+			if !stopped(p.Pid) {
+				continue
+			}
+
+			continued := make(chan os.Signal, 1)
+			signal.Notify(continued, syscall.SIGCONT)
+			setForegroundGroup(tty, syscall.Getpgrp())
+			syscall.Kill(-syscall.Getpgrp(), syscall.SIGTSTP)
+			<-continued
+			signal.Stop(continued)
+
+			if pgrp, err := foregroundGroup(tty); err == nil && pgrp == syscall.Getpgrp() {
+				setForegroundGroup(tty, p.Pid)
+			}
+			syscall.Kill(-p.Pid, syscall.SIGCONT)
+		case <-done:
+			return
+		}
+	}
+}
+
+// releaseTerminal gives the terminal tty back to gcvis once the command
+// it was handed to has exited.
+func releaseTerminal(tty int) {
+	setForegroundGroup(tty, syscall.Getpgrp())
+}
+
+func foregroundGroup(tty int) (int, error) {
+	var pgrp int32
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(tty), syscall.TIOCGPGRP, uintptr(unsafe.Pointer(&pgrp))); errno != 0 {
+		return 0, errno
+	}
+	return int(pgrp), nil
+}
+
+// setForegroundGroup puts the process group pgrp in the foreground of
+// the terminal tty. gcvis may be in the background itself, where the
+// terminal would stop it with SIGTTOU for trying, so that is ignored.
+func setForegroundGroup(tty, pgrp int) error {
+	signal.Ignore(syscall.SIGTTOU)
+	defer signal.Reset(syscall.SIGTTOU)
+
+	p := int32(pgrp)
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(tty), syscall.TIOCSPGRP, uintptr(unsafe.Pointer(&p))); errno != 0 {
+		return errno
+	}
+	return nil
+}
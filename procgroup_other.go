@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !windows
+
+package main
+
+// stopped reports that the child pid is running, as there is no way
+// here to ask whether it has stopped without reaping it.
+func stopped(pid int) bool {
+	return false
+}
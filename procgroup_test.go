@@ -0,0 +1,35 @@
+//go:build !windows
+
+package main
+
+import (
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSubCommandProcessGroup(t *testing.T) {
+	cmd := []string{"/usr/bin/env", "sleep", "5"}
+	subcommand := NewSubCommand(cmd)
+	go subcommand.Run()
+	defer subcommand.Signal(syscall.SIGKILL)
+
+	deadline := time.After(time.Second)
+	for !subcommand.running() {
+		select {
+		case <-deadline:
+			t.Fatalf("Execution timed out.")
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	// signals sent to the group reach every process the command starts,
+	// and none of gcvis's
+	pgid, err := syscall.Getpgid(subcommand.cmd.Process.Pid)
+	if err != nil {
+		t.Fatalf("Getpgid returned an error: %v", err)
+	}
+	if pgid == syscall.Getpgrp() || pgid != subcommand.cmd.Process.Pid {
+		t.Errorf("Expected the command to lead a process group of its own. Got group %d.", pgid)
+	}
+}
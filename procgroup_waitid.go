@@ -0,0 +1,19 @@
+//go:build linux || darwin
+
+package main
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// stopped reports whether the child pid has stopped, as ^Z stops it,
+// without waiting for it or reaping it if it has exited.
+func stopped(pid int) bool {
+	const pPID = 1 // P_PID, the idtype_t for a single process
+
+	// a siginfo_t, of which only si_signo, the first field, is read
+	var info [128]byte
+	_, _, errno := syscall.Syscall6(syscall.SYS_WAITID, pPID, uintptr(pid), uintptr(unsafe.Pointer(&info[0])), syscall.WSTOPPED|syscall.WNOHANG, 0, 0)
+	return errno == 0 && *(*int32)(unsafe.Pointer(&info[0])) == int32(syscall.SIGCHLD)
+}
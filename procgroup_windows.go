@@ -0,0 +1,21 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+)
+
+// ownProcessGroup leaves cmd as it is on Windows, where signals cannot be
+// sent to a group of processes.
+func ownProcessGroup(cmd *exec.Cmd) (tty int, ok bool) {
+	return 0, false
+}
+
+// signalGroup sends sig to p alone on Windows.
+func signalGroup(p *os.Process, sig os.Signal) error {
+	return p.Signal(sig)
+}
+
+func followStops(p *os.Process, tty int, done <-chan bool) {}
+
+func releaseTerminal(tty int) {}
@@ -0,0 +1,131 @@
+//go:build !windows
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/creack/pty"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// UsePTY runs the command on a pseudo-terminal, so that it behaves as it
+// does when started from a terminal. Its standard input, output and
+// error all go to the terminal, and everything it writes is copied as is
+// to out, if not nil, as well as to PipeRead. It must be called before
+// Run.
+func (s *SubCommand) UsePTY(out io.Writer) error {
+	s.onPTY = true
+	s.ptyOut = out
+	return nil
+}
+
+// startPTY starts the command on a new pseudo-terminal and copies between
+// it and gcvis until the command exits. When gcvis runs in a terminal,
+// that terminal is put in raw mode meanwhile, so that keys such as ^C go
+// to the command as typed, and its size is passed on. As raw mode also
+// stops the terminal from turning line feeds into new lines, gcvis's own
+// log lines end in a carriage return too until then.
+func (s *SubCommand) startPTY() error {
+	s.cmd.Stdin, s.cmd.Stdout, s.cmd.Stderr = nil, nil, nil
+	ptmx, err := pty.Start(s.cmd)
+	if err != nil {
+		return err
+	}
+
+	s.ptyDone = make(chan bool)
+	stdin := int(os.Stdin.Fd())
+	if terminal.IsTerminal(stdin) {
+		if state, err := terminal.MakeRaw(stdin); err == nil {
+			logOutput := log.Writer()
+			log.SetOutput(crlfWriter{logOutput})
+			s.restoreTerminal = func() {
+				terminal.Restore(stdin, state)
+				log.SetOutput(logOutput)
+			}
+		}
+		go s.resizePTY(ptmx)
+	}
+	go s.copyInput(ptmx)
+
+	out := io.Writer(s.pipeWrite)
+	if s.ptyOut != nil {
+		out = io.MultiWriter(s.ptyOut, s.pipeWrite)
+	}
+	go func() {
+		io.Copy(out, ptmx)
+		ptmx.Close()
+		close(s.ptyDone)
+	}()
+
+	return nil
+}
+
+// copyInput copies gcvis's standard input to the command until it exits.
+// A terminal is read through a file of its own, which unlike os.Stdin can
+// be stopped in the middle of a read, so that nothing typed after the
+// command exits is taken from gcvis.
+func (s *SubCommand) copyInput(ptmx *os.File) {
+	in := os.Stdin
+	if terminal.IsTerminal(int(in.Fd())) {
+		if tty, err := os.Open("/dev/tty"); err == nil {
+			defer tty.Close()
+			in = tty
+			go func() {
+				<-s.ptyDone
+				tty.SetReadDeadline(time.Now())
+			}()
+		}
+	}
+	io.Copy(ptmx, in)
+}
+
+// resizePTY keeps the size of the pseudo-terminal in step with the
+// terminal gcvis runs in.
+func (s *SubCommand) resizePTY(ptmx *os.File) {
+	winch := make(chan os.Signal, 1)
+	signal.Notify(winch, syscall.SIGWINCH)
+	defer signal.Stop(winch)
+
+	pty.InheritSize(os.Stdin, ptmx)
+	for {
+		select {
+		case <-winch:
+			pty.InheritSize(os.Stdin, ptmx)
+		case <-s.ptyDone:
+			return
+		}
+	}
+}
+
+// waitPTY waits for the output of the command to be copied, once it has
+// exited, and gives the terminal back.
+func (s *SubCommand) waitPTY() {
+	if s.ptyDone == nil {
+		return
+	}
+	<-s.ptyDone
+	if s.restoreTerminal != nil {
+		s.restoreTerminal()
+	}
+}
+
+// A crlfWriter ends every line written to w with a carriage return and a
+// line feed, for a terminal in raw mode.
+type crlfWriter struct {
+	w io.Writer
+}
+
+func (c crlfWriter) Write(b []byte) (int, error) {
+	if _, err := c.w.Write(bytes.ReplaceAll(b, []byte("\n"), []byte("\r\n"))); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
@@ -0,0 +1,53 @@
+//go:build !windows
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSubCommandPTY(t *testing.T) {
+	cmd := []string{"/usr/bin/env", "bash", "-c", "test -t 1 && test -t 2 && echo on a terminal 1>&2"}
+	subcommand := NewSubCommand(cmd)
+	out := &bytes.Buffer{}
+	if err := subcommand.UsePTY(out); err != nil {
+		t.Fatalf("UsePTY returned an error: %v", err)
+	}
+	done := make(chan bool)
+
+	go func() {
+		subcommand.Run()
+
+		content, err := ioutil.ReadAll(subcommand.PipeRead)
+		if err != nil {
+			t.Errorf("ReadAll returned an error: %v", err)
+		}
+		if strings.TrimRight(string(content), "\r\n ") != "on a terminal" {
+			t.Errorf("line is not equal to 'on a terminal': '%v'", string(content))
+		}
+
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		if out.String() != "on a terminal\r\n" {
+			t.Errorf("Expected terminal output to be passed through as is. Got %q instead.", out.String())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Execution timed out.")
+	}
+}
+
+func TestCRLFWriter(t *testing.T) {
+	out := &bytes.Buffer{}
+	crlfWriter{out}.Write([]byte("alert breached\nserver started\n"))
+
+	if out.String() != "alert breached\r\nserver started\r\n" {
+		t.Errorf("Expected lines to end in CRLF. Got %q instead.", out.String())
+	}
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"errors"
+	"io"
+)
+
+// UsePTY fails on Windows, which has no pseudo-terminals to run the
+// command on.
+func (s *SubCommand) UsePTY(out io.Writer) error {
+	return errors.New("pseudo-terminals are not supported on Windows")
+}
+
+func (s *SubCommand) startPTY() error {
+	return errors.New("pseudo-terminals are not supported on Windows")
+}
+
+func (s *SubCommand) waitPTY() {}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errRewind is returned by a lineSource that has started over.
+var errRewind = errors.New("source rewound")
+
+// A Recorder saves raw input lines along with the time they arrived, so
+// that a session can later be replayed with its original timing.
+//
+// Each line of a recording holds the arrival time in seconds, a tab, and
+// the raw line. Annotations are saved the same way, with an "a" before
+// the time.
+//
+// Recording stops at the first write error, which is logged.
+type Recorder struct {
+	w   io.Writer
+	err error
+	mu  sync.Mutex
+}
+
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{w: w}
+}
+
+func (r *Recorder) Record(elapsed float64, line string) {
+	r.write("%.6f\t%s\n", elapsed, line)
+}
+
+func (r *Recorder) RecordAnnotation(a Annotation) {
+	r.write("a%.6f\t%s\n", a.ElapsedTime, a.Label)
+}
+
+func (r *Recorder) write(format string, elapsed float64, text string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return
+	}
+	if _, r.err = fmt.Fprintf(r.w, format, elapsed, text); r.err != nil {
+		log.Printf("recording stopped: %v", r.err)
+	}
+}
+
+type recordEntry struct {
+	elapsed    float64
+	line       string
+	annotation bool
+}
+
+func readRecording(r io.Reader) ([]recordEntry, error) {
+	var entries []recordEntry
+
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		fields := strings.SplitN(sc.Text(), "\t", 2)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("recording line %d: missing timestamp", n)
+		}
+		annotation := strings.HasPrefix(fields[0], "a")
+		elapsed, err := strconv.ParseFloat(strings.TrimPrefix(fields[0], "a"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("recording line %d: %v", n, err)
+		}
+		entries = append(entries, recordEntry{elapsed: elapsed, line: fields[1], annotation: annotation})
+	}
+
+	// annotations are saved when they are posted, which is not when
+	// they are placed if they were given a time
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].elapsed < entries[j].elapsed })
+
+	return entries, sc.Err()
+}
+
+// An entrySource yields the lines of a recording all at once, without
+// waiting for their time to come.
+type entrySource struct {
+	entries []recordEntry
+}
+
+func (s *entrySource) Next() (inputLine, error) {
+	if len(s.entries) == 0 {
+		return inputLine{}, io.EOF
+	}
+	entry := s.entries[0]
+	s.entries = s.entries[1:]
+	return inputLine{Text: entry.line, Elapsed: entry.elapsed, Annotation: entry.annotation}, nil
+}
+
+// A Replayer feeds a recording back in real time, scaled by its speed.
+// It can be paused, resumed and moved to any point of the recording
+// while a Parser is reading from it. Input lines are paced by their
+// time; annotations, which carry the time they are placed at rather than
+// the time they were posted, follow the line before them at once.
+//
+// Once the end of the recording is reached Next returns io.EOF, so that
+// the session ends as it would for a live program, unless Hold is set.
+type Replayer struct {
+	// Hold keeps Next waiting at the end of the recording for the replay
+	// to be moved back, instead of returning io.EOF. Ended tells when the
+	// end is first reached.
+	Hold bool
+
+	entries []recordEntry
+	next    int
+	ended   chan bool
+	atEnd   bool
+
+	pos    float64   // position in the recording, in seconds
+	clock  time.Time // wall time at which pos was last advanced
+	speed  float64
+	paused bool
+	rewind bool
+
+	mu   sync.Mutex
+	wake chan bool
+}
+
+type replayStatus struct {
+	Position float64
+	Duration float64
+	Speed    float64
+	Paused   bool
+}
+
+// NewReplayer reads a recording made by a Recorder. A speed of zero
+// starts the replay paused at normal speed.
+func NewReplayer(r io.Reader, speed float64) (*Replayer, error) {
+	entries, err := readRecording(r)
+	if err != nil {
+		return nil, err
+	}
+
+	rp := &Replayer{
+		entries: entries,
+		clock:   time.Now(),
+		speed:   speed,
+		ended:   make(chan bool),
+		wake:    make(chan bool, 1),
+	}
+	if speed <= 0 {
+		rp.speed = 1
+		rp.paused = true
+	}
+
+	return rp, nil
+}
+
+func (r *Replayer) Next() (inputLine, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for {
+		if r.rewind {
+			r.rewind = false
+			r.next = 0
+			return inputLine{}, errRewind
+		}
+
+		r.advance()
+
+		var wait <-chan time.Time
+		if r.next == len(r.entries) {
+			if !r.atEnd {
+				r.atEnd = true
+				close(r.ended)
+			}
+			if !r.Hold {
+				return inputLine{}, io.EOF
+			}
+			r.mu.Unlock()
+			<-r.wake
+			r.mu.Lock()
+			continue
+		}
+
+		entry := r.entries[r.next]
+		if entry.annotation || entry.elapsed <= r.pos {
+			r.next++
+			return inputLine{Text: entry.line, Elapsed: entry.elapsed, Annotation: entry.annotation}, nil
+		}
+		if !r.paused {
+			wait = time.After(time.Duration((entry.elapsed - r.pos) / r.speed * float64(time.Second)))
+		}
+
+		r.mu.Unlock()
+		select {
+		case <-wait:
+		case <-r.wake:
+		}
+		r.mu.Lock()
+	}
+}
+
+// Ended is closed once the replay has reached the end of the recording.
+func (r *Replayer) Ended() <-chan bool {
+	return r.ended
+}
+
+func (r *Replayer) Play() {
+	r.control(func() { r.paused = false })
+}
+
+func (r *Replayer) Pause() {
+	r.control(func() { r.paused = true })
+}
+
+func (r *Replayer) SetSpeed(speed float64) {
+	if speed <= 0 {
+		return
+	}
+	r.control(func() { r.speed = speed })
+}
+
+// Seek moves the replay to the given position in seconds. Moving
+// backwards restarts the recording and replays everything up to pos at
+// once.
+func (r *Replayer) Seek(pos float64) {
+	r.control(func() {
+		if pos < 0 {
+			pos = 0
+		}
+		if r.next > 0 && r.entries[r.next-1].elapsed > pos {
+			r.rewind = true
+		}
+		r.pos = pos
+	})
+}
+
+func (r *Replayer) Status() replayStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.advance()
+
+	status := replayStatus{
+		Position: r.pos,
+		Speed:    r.speed,
+		Paused:   r.paused,
+	}
+	for i := len(r.entries) - 1; i >= 0; i-- {
+		if !r.entries[i].annotation {
+			status.Duration = r.entries[i].elapsed
+			break
+		}
+	}
+	if status.Position > status.Duration {
+		status.Position = status.Duration
+	}
+
+	return status
+}
+
+func (r *Replayer) control(f func()) {
+	r.mu.Lock()
+	r.advance()
+	f()
+	r.mu.Unlock()
+
+	select {
+	case r.wake <- true:
+	default:
+	}
+}
+
+func (r *Replayer) advance() {
+	now := time.Now()
+	if !r.paused {
+		r.pos += now.Sub(r.clock).Seconds() * r.speed
+	}
+	r.clock = now
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRecorderRoundTrip(t *testing.T) {
+	buf := &bytes.Buffer{}
+	recorder := NewRecorder(buf)
+	recorder.Record(0.5, "scvg1: inuse: 12, idle: 13, sys: 14, released: 15, consumed: 16 (MB)")
+	recorder.Record(1.25, "INFO: test")
+	recorder.RecordAnnotation(Annotation{ElapsedTime: 2, Label: "ramp start"})
+
+	replayer, err := NewReplayer(buf, 1000)
+	if err != nil {
+		t.Fatalf("NewReplayer returned an error: %v", err)
+	}
+
+	parser := NewReplayParser(replayer)
+	go parser.Run()
+
+	select {
+	case scvgTrace := <-parser.ScvgChan:
+		if scvgTrace.ElapsedTime != 0.5 {
+			t.Errorf("Expected scvgTrace to keep its recorded time 0.5. Got %v.", scvgTrace.ElapsedTime)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatalf("Execution timed out.")
+	}
+
+	select {
+	case line := <-parser.NoMatchChan:
+		if line != "INFO: test" {
+			t.Errorf("Expected line to equal 'INFO: test'. Got '%v'.", line)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatalf("Execution timed out.")
+	}
+
+	select {
+	case annotation := <-parser.AnnotationChan:
+		if annotation != (Annotation{ElapsedTime: 2, Label: "ramp start"}) {
+			t.Errorf("Unexpected annotation: %+v", annotation)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatalf("Execution timed out.")
+	}
+}
+
+func TestReplayerSeekBackwards(t *testing.T) {
+	replayer, err := NewReplayer(bytes.NewBufferString("1.0\tfirst\n2.0\tsecond\n"), 0)
+	if err != nil {
+		t.Fatalf("NewReplayer returned an error: %v", err)
+	}
+
+	replayer.Seek(5)
+	for _, expected := range []string{"first", "second"} {
+		in, err := replayer.Next()
+		if err != nil || in.Text != expected {
+			t.Fatalf("Expected '%v'. Got '%v' (%v).", expected, in.Text, err)
+		}
+	}
+
+	replayer.Seek(1.5)
+	if _, err := replayer.Next(); err != errRewind {
+		t.Fatalf("Expected errRewind after seeking backwards. Got %v.", err)
+	}
+
+	in, err := replayer.Next()
+	if err != nil || in.Text != "first" {
+		t.Fatalf("Expected 'first'. Got '%v' (%v).", in.Text, err)
+	}
+
+	if status := replayer.Status(); status.Position != 1.5 || status.Duration != 2.0 || !status.Paused {
+		t.Errorf("Unexpected replay status: %+v", status)
+	}
+}
+
+func TestReplayerEnd(t *testing.T) {
+	replayer, err := NewReplayer(bytes.NewBufferString("0.001\tonly\n"), 1000)
+	if err != nil {
+		t.Fatalf("NewReplayer returned an error: %v", err)
+	}
+
+	if in, err := replayer.Next(); err != nil || in.Text != "only" {
+		t.Fatalf("Expected 'only'. Got '%v' (%v).", in.Text, err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := replayer.Next()
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != io.EOF {
+			t.Errorf("Expected io.EOF at the end of the recording. Got %v.", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatalf("Execution timed out.")
+	}
+}
+
+func TestReplayerHold(t *testing.T) {
+	replayer, err := NewReplayer(bytes.NewBufferString("0.001\tonly\n"), 1000)
+	if err != nil {
+		t.Fatalf("NewReplayer returned an error: %v", err)
+	}
+	replayer.Hold = true
+
+	if in, err := replayer.Next(); err != nil || in.Text != "only" {
+		t.Fatalf("Expected 'only'. Got '%v' (%v).", in.Text, err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := replayer.Next()
+		done <- err
+	}()
+
+	select {
+	case <-replayer.Ended():
+	case <-time.After(100 * time.Millisecond):
+		t.Fatalf("Execution timed out.")
+	}
+	select {
+	case err := <-done:
+		t.Fatalf("Expected the replay to be held at the end. Got %v.", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	replayer.Seek(0)
+	select {
+	case err := <-done:
+		if err != errRewind {
+			t.Errorf("Expected errRewind after seeking back from the end. Got %v.", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatalf("Execution timed out.")
+	}
+}
+
+func TestReplayerAnnotations(t *testing.T) {
+	// an annotation posted early in the session, for a time further on
+	recording := "0.001\tfirst\na100.000000\tlater\n0.002\tsecond\n"
+	replayer, err := NewReplayer(bytes.NewBufferString(recording), 1)
+	if err != nil {
+		t.Fatalf("NewReplayer returned an error: %v", err)
+	}
+
+	var texts []string
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < 3; i++ {
+			in, err := replayer.Next()
+			if err != nil {
+				break
+			}
+			texts = append(texts, in.Text)
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Execution timed out.")
+	}
+	if expected := []string{"first", "second", "later"}; !reflect.DeepEqual(texts, expected) {
+		t.Errorf("Expected the recording in time order, %v. Got %v instead.", expected, texts)
+	}
+	if status := replayer.Status(); status.Duration != 0.002 {
+		t.Errorf("Expected the duration of the input lines, 0.002. Got %v instead.", status.Duration)
+	}
+}
+
+func TestReplayerMalformedRecording(t *testing.T) {
+	if _, err := NewReplayer(bytes.NewBufferString("no timestamp here\n"), 1); err == nil {
+		t.Fatalf("Expected an error for a line without a timestamp.")
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// A Report describes a whole run once its input has ended.
+type Report struct {
+	Title    string
+	Summary  Summary
+	PeakGoal int64 // in megabytes
+	PeakLive int64 // in megabytes
+	LastLive int64 // in megabytes
+
+	// PauseBuckets counts the cycles whose pause, in milliseconds, is at
+	// most the matching PauseBounds entry and above the previous one.
+	PauseBounds  []float64
+	PauseBuckets []int
+
+	// WarmupCycles counts the cycles left out as the warm-up, which
+	// ended at WarmupEnd seconds.
+	WarmupCycles int
+	WarmupEnd    float64
+
+	Breaches   []Breach
+	Benchmarks []BenchmarkStats
+	Runs       []RunStats
+}
+
+func (g *Graph) Report() Report {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	traces, warmupEnd := g.measured()
+	r := Report{
+		Title:        g.Title,
+		Summary:      summarize(traces, warmupEnd),
+		WarmupCycles: len(g.gcTraces) - len(traces),
+		WarmupEnd:    warmupEnd,
+		Breaches:     g.Breaches,
+		Benchmarks:   benchmarkStats(traces),
+		Runs:         runStats(traces),
+		PauseBounds:  make([]float64, len(pauseBuckets)),
+		PauseBuckets: make([]int, len(pauseBuckets)+1),
+	}
+	for i, le := range pauseBuckets {
+		r.PauseBounds[i] = le * 1000
+	}
+
+	for _, t := range traces {
+		if t.Heap1 > r.PeakGoal {
+			r.PeakGoal = t.Heap1
+		}
+		if t.HeapLive > r.PeakLive {
+			r.PeakLive = t.HeapLive
+		}
+		r.LastLive = t.HeapLive
+
+		i := 0
+		for i < len(r.PauseBounds) && pauseMs(t) > r.PauseBounds[i] {
+			i++
+		}
+		r.PauseBuckets[i]++
+	}
+
+	return r
+}
+
+func (r Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+func (r Report) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	s := r.Summary
+
+	fmt.Fprintf(tw, "gcvis report\t%s\n", r.Title)
+	if r.WarmupEnd > 0 {
+		fmt.Fprintf(tw, "warm-up\t%d cycles until %.3fs, left out\n", r.WarmupCycles, r.WarmupEnd)
+	}
+	fmt.Fprintf(tw, "cycles\t%d\n", s.Cycles)
+	fmt.Fprintf(tw, "wall time\t%.3fs\n", s.WallTime)
+	fmt.Fprintf(tw, "GCs per minute\t%.1f\n", s.GCPerMinute)
+	fmt.Fprintf(tw, "mean interval\t%.3fs\n", s.MeanInterval)
+	fmt.Fprintf(tw, "pause p50/p95/p99/max\t%.3f / %.3f / %.3f / %.3f ms\n", s.PauseP50, s.PauseP95, s.PauseP99, s.PauseMax)
+	fmt.Fprintf(tw, "peak heap goal\t%d MB\n", r.PeakGoal)
+	fmt.Fprintf(tw, "live heap peak/last\t%d / %d MB\n", r.PeakLive, r.LastLive)
+	fmt.Fprintf(tw, "GC cpu\t%.3fs (%.2f%% of available cpu)\n", s.GCCPU/1000, s.GCCPUFraction*100)
+	fmt.Fprintf(tw, "assist share\t%.1f%%\n", s.AssistShare*100)
+
+	fmt.Fprintf(tw, "\npause\tcycles\n")
+	for i, n := range r.PauseBuckets {
+		if i < len(r.PauseBounds) {
+			fmt.Fprintf(tw, "<= %.4gms\t%d\n", r.PauseBounds[i], n)
+		} else {
+			fmt.Fprintf(tw, "> %.4gms\t%d\n", r.PauseBounds[i-1], n)
+		}
+	}
+
+	if len(r.Breaches) > 0 {
+		fmt.Fprintf(tw, "\nalerts\t\n")
+		for _, b := range r.Breaches {
+			fmt.Fprintf(tw, "%.3fs\t%s (%s)\n", b.ElapsedTime, b.Rule, formatFloat(b.Value))
+		}
+	}
+
+	if len(r.Benchmarks) > 0 {
+		fmt.Fprintf(tw, "\nbenchmark\tcycles\tpause total\tpeak goal\tpeak live\n")
+		for _, b := range r.Benchmarks {
+			fmt.Fprintf(tw, "%s\t%s\n", b.Name, b.columns())
+		}
+	}
+
+	if len(r.Runs) > 0 {
+		fmt.Fprintf(tw, "\nrun\tfrom\tto\tcycles\tpause total\tpeak goal\tpeak live\n")
+		for _, run := range r.Runs {
+			fmt.Fprintf(tw, "%d\t%.3fs\t%.3fs\t%s\n", run.Run, run.Start, run.End, run.columns())
+		}
+	}
+
+	return tw.Flush()
+}
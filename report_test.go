@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func reportGraph() *Graph {
+	graph := NewGraph("fake title", GCVIS_TMPL)
+	graph.AddGCTraceGraphPoint(&gctrace{ElapsedTime: 1, Heap1: 8, HeapLive: 6, STWSclock: 0.02})
+	graph.AddGCTraceGraphPoint(&gctrace{ElapsedTime: 2, Heap1: 16, HeapLive: 4, STWSclock: 2})
+	return &graph
+}
+
+func TestGraphReport(t *testing.T) {
+	r := reportGraph().Report()
+
+	if r.Summary.Cycles != 2 || r.PeakGoal != 16 || r.PeakLive != 6 || r.LastLive != 4 {
+		t.Errorf("Unexpected report: %+v", r)
+	}
+
+	if len(r.PauseBuckets) != len(r.PauseBounds)+1 {
+		t.Fatalf("Expected one more pause bucket than bounds. Got %d and %d.", len(r.PauseBuckets), len(r.PauseBounds))
+	}
+	total := 0
+	for _, n := range r.PauseBuckets {
+		total += n
+	}
+	if total != 2 || r.PauseBuckets[1] != 1 || r.PauseBuckets[5] != 1 {
+		t.Errorf("Unexpected pause buckets: %v", r.PauseBuckets)
+	}
+}
+
+func TestReportWriteTable(t *testing.T) {
+	w := &bytes.Buffer{}
+	if err := reportGraph().Report().WriteTable(w); err != nil {
+		t.Fatalf("WriteTable returned an error: %v", err)
+	}
+
+	for _, expected := range []string{"cycles", "peak heap goal         16 MB", "<= 0.05ms", "> 1000ms"} {
+		if !strings.Contains(w.String(), expected) {
+			t.Errorf("Expected table to contain %q. Got:\n%v", expected, w.String())
+		}
+	}
+}
+
+func TestReportWriteJSON(t *testing.T) {
+	w := &bytes.Buffer{}
+	if err := reportGraph().Report().WriteJSON(w); err != nil {
+		t.Fatalf("WriteJSON returned an error: %v", err)
+	}
+
+	var r Report
+	if err := json.Unmarshal(w.Bytes(), &r); err != nil {
+		t.Fatalf("Report JSON didn't decode: %v", err)
+	}
+	if r.Title != "fake title" || r.PeakGoal != 16 {
+		t.Errorf("Unexpected decoded report: %+v", r)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// A runTracker follows the runs of a program through its GC traces.
+type runTracker struct {
+	run       int
+	offset    float64 // where the current run starts on the timeline
+	end       float64 // time of the last trace on the timeline
+	lastNumGC int64
+	lastTime  float64 // timestamp of the last trace, as the program gave it
+	skew      float64 // how far the last timed trace was moved from its arrival
+}
+
+// trackRun tags a trace with the run of the program it came from. A
+// restarted program numbers its cycles and times them from zero again,
+// as in the log of a service, so a trace going back on either starts a
+// new run. Runs are laid out one after another: each starts where the
+// last one ended, or when its first trace arrived if that is later, and
+// the restart is marked on the charts.
+func (p *Parser) trackRun(gc *gctrace, elapsed float64, timed bool) {
+	r := &p.runs
+	own := gc.ElapsedTime
+
+	switch {
+	case r.run == 0:
+		r.run = 1
+	case gc.NumGC <= r.lastNumGC || (timed && own < r.lastTime):
+		r.run++
+		start := elapsed
+		if timed {
+			r.offset = math.Max(r.end, elapsed-own)
+			start = r.offset
+		}
+		p.AnnotationChan <- NewAnnotation(start, fmt.Sprintf("restart, run %d", r.run))
+	}
+
+	r.lastNumGC, r.lastTime = gc.NumGC, own
+	gc.Run = r.run
+	if timed {
+		gc.ElapsedTime = own + r.offset
+		p.skewMu.Lock()
+		r.skew = gc.ElapsedTime - elapsed
+		p.skewMu.Unlock()
+	}
+	r.end = gc.ElapsedTime
+}
+
+// place puts a line that carries no time of its own, such as a scvg
+// trace or a mark, on the timeline next to the traces around it. It is
+// moved as far as the last timed trace was, so that it keeps its place
+// among the traces after a restart.
+func (p *Parser) place(elapsed float64) float64 {
+	p.skewMu.Lock()
+	defer p.skewMu.Unlock()
+	return elapsed + p.runs.skew
+}
+
+// Now returns where a line arriving now would be placed on the timeline,
+// as place puts it: at the time since the input started, or the position
+// of a replay, moved as far as the last timed trace was.
+func (p *Parser) Now() float64 {
+	var elapsed float64
+	switch s := p.source.(type) {
+	case *readerSource:
+		elapsed = time.Now().Sub(s.start).Seconds()
+	case *Replayer:
+		elapsed = s.Status().Position
+	default:
+		elapsed = time.Now().Sub(StartTime).Seconds()
+	}
+	return p.place(elapsed)
+}
+
+// RunStats describes the GC cycles of one run of a program that was
+// restarted.
+type RunStats struct {
+	Run   int
+	Start float64 // time of the first cycle, in seconds
+	End   float64 // time of the last cycle, in seconds
+	CycleStats
+}
+
+// runStats groups traces by the run of the program they came from. It
+// returns nothing unless the program was restarted.
+func runStats(traces []*gctrace) []RunStats {
+	var stats []RunStats
+	for _, t := range traces {
+		if n := len(stats); n == 0 || stats[n-1].Run != t.Run {
+			stats = append(stats, RunStats{Run: t.Run, Start: t.ElapsedTime})
+		}
+
+		s := &stats[len(stats)-1]
+		s.End = t.ElapsedTime
+		s.add(t)
+	}
+	if len(stats) < 2 {
+		return nil
+	}
+	return stats
+}
+
+func (g *Graph) Runs() []RunStats {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	traces, _ := g.measured()
+	return runStats(traces)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParserRestarts(t *testing.T) {
+	input := strings.Join([]string{
+		gcLine(1, 1, 0.25, 4, 5, 2, 5),
+		gcLine(2, 2, 0.25, 5, 9, 3, 9),
+		"starting server",
+		gcLine(1, 0.5, 0.5, 4, 6, 3, 6),
+		gcLine(2, 1.5, 0.5, 6, 7, 4, 7),
+		"scvg0: inuse: 4, idle: 1, sys: 5, released: 0, consumed: 5 (MB)",
+		gcLine(7, 0.25, 0.5, 4, 4, 2, 4),
+	}, "\n")
+
+	parser := NewParser(bytes.NewReader([]byte(input)))
+	graph, err := collectSession("service.log", parser, ioutil.Discard)
+	if err != nil {
+		t.Fatalf("collectSession returned an error: %v", err)
+	}
+
+	var times []float64
+	for _, p := range graph.HeapUse {
+		times = append(times, p[0])
+	}
+	if expected := []float64{1, 2, 2.5, 3.5, 3.75}; !reflect.DeepEqual(times, expected) {
+		t.Errorf("Expected runs to follow one another at %v. Got %v instead.", expected, times)
+	}
+
+	expected := []RunStats{
+		{Run: 1, Start: 1, End: 2, CycleStats: CycleStats{Cycles: 2, PauseTotal: 1, PeakGoal: 9, PeakLive: 3}},
+		{Run: 2, Start: 2.5, End: 3.5, CycleStats: CycleStats{Cycles: 2, PauseTotal: 2, PeakGoal: 7, PeakLive: 4}},
+		{Run: 3, Start: 3.75, End: 3.75, CycleStats: CycleStats{Cycles: 1, PauseTotal: 1, PeakGoal: 4, PeakLive: 2}},
+	}
+	if runs := graph.Runs(); !reflect.DeepEqual(runs, expected) {
+		t.Errorf("Expected runs to equal %+v. Got %+v instead.", expected, runs)
+	}
+
+	if labels, expected := annotationLabels(graph), []string{"restart, run 2", "restart, run 3"}; !reflect.DeepEqual(labels, expected) {
+		t.Errorf("Expected every restart to be annotated as %v. Got %v instead.", expected, labels)
+	}
+
+	if len(graph.ScvgInuse) != 1 || graph.ScvgInuse[0][0] < 3.5 || graph.ScvgInuse[0][0] > 3.6 {
+		t.Errorf("Expected the scvg line to keep its place after the second run's traces. Got %v.", graph.ScvgInuse)
+	}
+	if now := parser.Now(); now < 3.75 || now > 3.85 {
+		t.Errorf("Expected now to follow the last run's clock, at about 3.75. Got %v instead.", now)
+	}
+
+	if len(graph.AllocRate) != 2 {
+		t.Errorf("Expected no alloc rate across a restart. Got %v.", graph.AllocRate)
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// rollingWindow is the span, in seconds, of the rolling statistics.
+const rollingWindow = 60
+
+// A Summary condenses a run of GC cycles into the numbers used to judge
+// whether the collector is behaving. Pauses are in milliseconds, times
+// in seconds.
+type Summary struct {
+	Cycles        int
+	WallTime      float64
+	PauseP50      float64
+	PauseP95      float64
+	PauseP99      float64
+	PauseMax      float64
+	GCPerMinute   float64
+	MeanInterval  float64
+	GCCPU         float64 // total GC CPU time, in milliseconds
+	GCCPUFraction float64 // share of the available CPU spent in GC
+	AssistShare   float64 // share of GC CPU spent in mark assists
+}
+
+type sessionSummary struct {
+	Session Summary
+	Rolling Summary
+	Window  float64
+}
+
+// CycleStats sums up a group of GC cycles, such as those of one
+// benchmark or one run of the program.
+type CycleStats struct {
+	Cycles     int
+	PauseTotal float64 // in milliseconds
+	PeakGoal   int64   // in megabytes
+	PeakLive   int64   // in megabytes
+}
+
+func (s *CycleStats) add(t *gctrace) {
+	s.Cycles++
+	s.PauseTotal += pauseMs(t)
+	if t.Heap1 > s.PeakGoal {
+		s.PeakGoal = t.Heap1
+	}
+	if t.HeapLive > s.PeakLive {
+		s.PeakLive = t.HeapLive
+	}
+}
+
+// columns returns the statistics as the tab separated columns of a
+// report table.
+func (s CycleStats) columns() string {
+	return fmt.Sprintf("%d\t%.3f ms\t%d MB\t%d MB", s.Cycles, s.PauseTotal, s.PeakGoal, s.PeakLive)
+}
+
+// gcCPUMs is the total CPU time of a cycle, in milliseconds.
+func gcCPUMs(t *gctrace) float64 {
+	return t.STWScpu + t.MASAssistcpu + t.MASBGcpu + t.MASIdlecpu + t.STWMcpu
+}
+
+// summarize computes the statistics of traces, which span the period
+// from since to the last trace.
+func summarize(traces []*gctrace, since float64) Summary {
+	var s Summary
+	s.Cycles = len(traces)
+	if s.Cycles == 0 {
+		return s
+	}
+
+	pauses := make([]float64, len(traces))
+	var assist float64
+	for i, t := range traces {
+		pauses[i] = pauseMs(t)
+		s.GCCPU += gcCPUMs(t)
+		assist += t.MASAssistcpu
+	}
+	sort.Float64s(pauses)
+	s.PauseP50 = percentile(pauses, 50)
+	s.PauseP95 = percentile(pauses, 95)
+	s.PauseP99 = percentile(pauses, 99)
+	s.PauseMax = pauses[len(pauses)-1]
+
+	first, last := traces[0], traces[len(traces)-1]
+	s.WallTime = last.ElapsedTime - since
+	if span := last.ElapsedTime - first.ElapsedTime; span > 0 {
+		s.MeanInterval = span / float64(s.Cycles-1)
+		s.GCPerMinute = 60 / s.MeanInterval
+	}
+
+	procs := float64(last.Nproc)
+	if procs == 0 {
+		procs = 1
+	}
+	if s.WallTime > 0 {
+		s.GCCPUFraction = s.GCCPU / (s.WallTime * 1000 * procs)
+	}
+	if s.GCCPU > 0 {
+		s.AssistShare = assist / s.GCCPU
+	}
+
+	return s
+}
+
+// percentile returns the nearest-rank percentile p of sorted values.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// Summary returns statistics over the whole session and over the last
+// rollingWindow seconds of it, leaving out the warm-up.
+func (g *Graph) Summary() sessionSummary {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	traces, warmupEnd := g.measured()
+	summary := sessionSummary{
+		Session: summarize(traces, warmupEnd),
+		Window:  rollingWindow,
+	}
+
+	if n := len(traces); n > 0 {
+		since := traces[n-1].ElapsedTime - rollingWindow
+		i := sort.Search(n, func(i int) bool { return traces[i].ElapsedTime >= since })
+		summary.Rolling = summarize(traces[i:], math.Max(since, warmupEnd))
+	}
+
+	return summary
+}
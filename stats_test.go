@@ -0,0 +1,56 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSummarize(t *testing.T) {
+	var traces []*gctrace
+	for i := 1; i <= 10; i++ {
+		traces = append(traces, &gctrace{
+			ElapsedTime:  float64(i),
+			Nproc:        2,
+			STWSclock:    float64(i),
+			MASAssistcpu: 50,
+			MASBGcpu:     150,
+		})
+	}
+
+	s := summarize(traces, 0)
+
+	expected := Summary{
+		Cycles:        10,
+		WallTime:      10,
+		PauseP50:      5,
+		PauseP95:      10,
+		PauseP99:      10,
+		PauseMax:      10,
+		GCPerMinute:   60,
+		MeanInterval:  1,
+		GCCPU:         2000,
+		GCCPUFraction: 0.1,
+		AssistShare:   0.25,
+	}
+	if s != expected {
+		t.Errorf("Expected summary to equal %+v. Got %+v instead.", expected, s)
+	}
+}
+
+func TestGraphSummaryRolling(t *testing.T) {
+	graph := NewGraph("fake title", GCVIS_TMPL)
+	graph.AddGCTraceGraphPoint(&gctrace{ElapsedTime: 1, STWSclock: 100})
+	graph.AddGCTraceGraphPoint(&gctrace{ElapsedTime: 100, STWSclock: 1})
+	graph.AddGCTraceGraphPoint(&gctrace{ElapsedTime: 110, STWSclock: 2})
+
+	summary := graph.Summary()
+	if summary.Session.Cycles != 3 || summary.Session.PauseMax != 100 {
+		t.Errorf("Unexpected session summary: %+v", summary.Session)
+	}
+	if summary.Rolling.Cycles != 2 || summary.Rolling.PauseMax != 2 {
+		t.Errorf("Unexpected rolling summary: %+v", summary.Rolling)
+	}
+	if math.Abs(summary.Rolling.WallTime-rollingWindow) > 1e-9 {
+		t.Errorf("Expected rolling wall time to span the window. Got %v.", summary.Rolling.WallTime)
+	}
+}
@@ -1,18 +1,101 @@
 package main
 
 import (
+	"fmt"
 	"io"
 	"log"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 	"sync"
+	"syscall"
+	"time"
 )
 
+// DefaultGODEBUG turns on the GC traces that gcvis reads.
+const DefaultGODEBUG = "gctrace=1"
+
+// stopGrace is how long a stopped command has to exit before it is
+// killed.
+const stopGrace = 10 * time.Second
+
+var runtimeEnvVars = []string{"GODEBUG", "GOGC", "GOMEMLIMIT", "GOMAXPROCS", "GOTRACEBACK"}
+
+// mergeGODEBUG adds settings to a GODEBUG value. Settings already in
+// current are replaced, and the others are kept.
+func mergeGODEBUG(current, settings string) string {
+	var merged []string
+	keys := map[string]bool{}
+	for _, kv := range strings.Split(settings, ",") {
+		if kv = strings.TrimSpace(kv); kv != "" {
+			merged = append(merged, kv)
+			keys[strings.SplitN(kv, "=", 2)[0]] = true
+		}
+	}
+
+	var kept []string
+	for _, kv := range strings.Split(current, ",") {
+		if kv = strings.TrimSpace(kv); kv != "" && !keys[strings.SplitN(kv, "=", 2)[0]] {
+			kept = append(kept, kv)
+		}
+	}
+
+	return strings.Join(append(kept, merged...), ",")
+}
+
+// isGoRun reports whether args run go run or go test, which build a
+// program and then run it.
+func isGoRun(args []string) bool {
+	if len(args) < 2 || strings.TrimSuffix(filepath.Base(args[0]), ".exe") != "go" {
+		return false
+	}
+	return args[1] == "run" || args[1] == "test"
+}
+
+// isGoTest reports whether args run go test, which passes the standard
+// error of the test binary, and so its traces, on to its standard output.
+func isGoTest(args []string) bool {
+	return isGoRun(args) && args[1] == "test"
+}
+
+// goExecArgs rewrites a go run or go test command to start the program
+// it builds through exec, so that exec can set up the environment of that
+// program alone. It returns false for other commands, and for those that
+// already pass -exec.
+func goExecArgs(args []string, exec string) ([]string, bool) {
+	if !isGoRun(args) {
+		return nil, false
+	}
+	for _, arg := range args[2:] {
+		name := strings.SplitN(strings.TrimLeft(arg, "-"), "=", 2)[0]
+		if strings.HasPrefix(arg, "-") && name == "exec" {
+			return nil, false
+		}
+	}
+
+	return append([]string{args[0], args[1], "-exec", exec}, args[2:]...), true
+}
+
 type SubCommand struct {
 	cmd       *exec.Cmd
+	args      []string
 	PipeRead  io.ReadCloser
 	pipeWrite io.WriteCloser
+	godebug   string
 	err       error
+	started   bool
+	exited    bool
+	done      chan bool
+	stopped   time.Duration
+
+	tty        int // the terminal handed to the command, if onTerminal
+	onTerminal bool
+
+	onPTY           bool
+	ptyOut          io.Writer
+	ptyDone         chan bool
+	restoreTerminal func()
 
 	errMtx sync.Mutex
 }
@@ -23,23 +106,248 @@ func NewSubCommand(args []string) *SubCommand {
 		log.Fatal(err)
 	}
 
-	env := append(os.Environ(), "GODEBUG=gctrace=1")
 	cmd := exec.Command(args[0], args[1:]...)
-	cmd.Env = env
+	cmd.Env = os.Environ()
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = pipeWrite
 
-	return &SubCommand{
+	s := &SubCommand{
 		cmd:       cmd,
+		args:      args,
 		PipeRead:  pipeRead,
 		pipeWrite: pipeWrite,
+		done:      make(chan bool),
+	}
+	s.SetGODEBUG(DefaultGODEBUG)
+	if isGoTest(args) {
+		s.CaptureStdout()
 	}
+
+	return s
+}
+
+// SetGODEBUG sets the runtime trace settings, such as
+// gctrace=1,scavtrace=1, merged into the command's GODEBUG. It must be
+// called before Run.
+//
+// For go run and go test, the settings are given to the program being
+// built only, by having go start it through gcvis with its -exec flag,
+// so that the traces of the go command, compiler and linker are left out.
+func (s *SubCommand) SetGODEBUG(settings string) {
+	current, isSet := os.LookupEnv("GODEBUG")
+	s.godebug = mergeGODEBUG(current, settings)
+	if exec, ok := execHelper(s.godebug); ok {
+		if args, ok := goExecArgs(s.args, exec); ok {
+			s.cmd.Args = args
+			if isSet {
+				s.Setenv("GODEBUG", current)
+			} else {
+				s.Unsetenv("GODEBUG")
+			}
+			return
+		}
+	}
+	s.Setenv("GODEBUG", s.godebug)
+}
+
+// Setenv sets a variable of the command's environment. It must be called
+// before Run.
+func (s *SubCommand) Setenv(name, value string) {
+	s.Unsetenv(name)
+	s.cmd.Env = append(s.cmd.Env, name+"="+value)
+}
+
+// Unsetenv removes a variable from the command's environment. It must be
+// called before Run.
+func (s *SubCommand) Unsetenv(name string) {
+	var env []string
+	for _, kv := range s.cmd.Env {
+		if !strings.HasPrefix(kv, name+"=") {
+			env = append(env, kv)
+		}
+	}
+	s.cmd.Env = env
+}
+
+// RuntimeEnv returns the variables of the traced program's environment
+// that tune the Go runtime. The rest is left out, as it may hold secrets.
+func (s *SubCommand) RuntimeEnv() []string {
+	env := []string{"GODEBUG=" + s.godebug}
+	for _, kv := range s.cmd.Env {
+		for _, name := range runtimeEnvVars {
+			if name != "GODEBUG" && strings.HasPrefix(kv, name+"=") {
+				env = append(env, kv)
+			}
+		}
+	}
+	return env
+}
+
+// SetStdin sets the standard input of the command, the one of gcvis by
+// default. It must be called before Run.
+func (s *SubCommand) SetStdin(r io.Reader) {
+	s.cmd.Stdin = r
+}
+
+// SetStdout sends the standard output of the command to w. It must be
+// called before Run.
+func (s *SubCommand) SetStdout(w io.Writer) {
+	s.cmd.Stdout = w
+}
+
+// CaptureStdout sends the standard output of the command to PipeRead
+// along with its standard error. It must be called before Run. The
+// output of go test is captured from the start.
+func (s *SubCommand) CaptureStdout() {
+	s.cmd.Stdout = s.pipeWrite
+}
+
+func (s *SubCommand) capturesStdout() bool {
+	return s.cmd.Stdout == s.pipeWrite
 }
 
 func (s *SubCommand) Run() {
-	s.setErr(s.cmd.Run())
+	err := s.start()
+	if err == nil {
+		err = s.cmd.Wait()
+		s.waitPTY()
+		if s.onTerminal {
+			releaseTerminal(s.tty)
+		}
+	}
+	s.setErr(err)
 	s.pipeWrite.Close()
+	close(s.done)
+}
+
+// Done is closed once Run has returned.
+func (s *SubCommand) Done() <-chan bool {
+	return s.done
+}
+
+func (s *SubCommand) start() error {
+	s.errMtx.Lock()
+	defer s.errMtx.Unlock()
+
+	start := s.cmd.Start
+	if s.onPTY {
+		start = s.startPTY
+	} else {
+		s.tty, s.onTerminal = ownProcessGroup(s.cmd)
+	}
+	if err := start(); err != nil {
+		return err
+	}
+	s.started = true
+	if s.onTerminal {
+		go followStops(s.cmd.Process, s.tty, s.done)
+	}
+	return nil
+}
+
+func (s *SubCommand) running() bool {
+	s.errMtx.Lock()
+	defer s.errMtx.Unlock()
+	return s.started && !s.exited
+}
+
+// Signal sends sig to the command if it is running, along with every
+// process it has started, which run in its process group.
+func (s *SubCommand) Signal(sig os.Signal) error {
+	s.errMtx.Lock()
+	defer s.errMtx.Unlock()
+
+	if !s.started || s.exited {
+		return nil
+	}
+	return signalGroup(s.cmd.Process, sig)
+}
+
+// Stop asks the command to exit with an interrupt, and kills it if it
+// is still running after grace.
+func (s *SubCommand) Stop(grace time.Duration) {
+	if err := s.Signal(os.Interrupt); err != nil {
+		log.Printf("stopping: %v", err)
+	}
+
+	select {
+	case <-s.Done():
+	case <-time.After(grace):
+		s.Signal(os.Kill)
+	}
+}
+
+// StopAfter stops the command as Stop does once it has run for d, unless
+// it has exited by then. A command that ends from the interrupt is taken
+// to have run to completion.
+func (s *SubCommand) StopAfter(d, grace time.Duration) {
+	timer := time.AfterFunc(d, func() {
+		s.errMtx.Lock()
+		s.stopped = d
+		s.errMtx.Unlock()
+		s.Stop(grace)
+	})
+	go func() {
+		<-s.Done()
+		timer.Stop()
+	}()
+}
+
+// interrupted reports whether the command was ended by the interrupt
+// StopAfter sent. The go command reports a program it runs that was
+// ended by a signal with a status of 1.
+func (s *SubCommand) interrupted(state *os.ProcessState) bool {
+	if s.stopped == 0 {
+		return false
+	}
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() && status.Signal() == os.Interrupt {
+		return true
+	}
+	return isGoRun(s.args) && state.ExitCode() == 1
+}
+
+// ExitCode is the status to exit with once Run has returned: the
+// command's own exit code, 128 plus the signal that killed it, or 1 if
+// it could not be started.
+func (s *SubCommand) ExitCode() int {
+	s.errMtx.Lock()
+	defer s.errMtx.Unlock()
+
+	state := s.cmd.ProcessState
+	if state == nil {
+		if s.err != nil {
+			return 1
+		}
+		return 0
+	}
+	if s.interrupted(state) {
+		return 0
+	}
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return state.ExitCode()
+}
+
+// ExitMessage describes how the command ended, once Run has returned.
+func (s *SubCommand) ExitMessage() string {
+	s.errMtx.Lock()
+	defer s.errMtx.Unlock()
+
+	state := s.cmd.ProcessState
+	switch {
+	case state == nil && s.err != nil:
+		return fmt.Sprintf("process failed to start: %v", s.err)
+	case state == nil:
+		return "process is running"
+	case s.interrupted(state) || (s.stopped > 0 && state.Success()):
+		return fmt.Sprintf("process stopped after %v", s.stopped)
+	case state.Exited():
+		return fmt.Sprintf("process exited with status %d", state.ExitCode())
+	default:
+		return fmt.Sprintf("process ended: %v", state)
+	}
 }
 
 func (s *SubCommand) Err() error {
@@ -52,4 +360,5 @@ func (s *SubCommand) setErr(err error) {
 	s.errMtx.Lock()
 	defer s.errMtx.Unlock()
 	s.err = err
+	s.exited = true
 }
@@ -2,7 +2,11 @@ package main
 
 import (
 	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
+	"syscall"
 	"testing"
 	"time"
 )
@@ -60,8 +64,200 @@ func TestSubCommandFail(t *testing.T) {
 		if subcommand.Err() == nil {
 			t.Errorf("Expected subcommand to have an error assigned.")
 		}
+		if msg := subcommand.ExitMessage(); msg != "process exited with status 1" {
+			t.Errorf("Expected exit message to report status 1. Got %q instead.", msg)
+		}
 		return
 	case <-time.After(100 * time.Millisecond):
 		t.Fatalf("Execution timed out.")
 	}
 }
+
+func TestSubCommandSignal(t *testing.T) {
+	cmd := []string{"/usr/bin/env", "sleep", "5"}
+	subcommand := NewSubCommand(cmd)
+	go subcommand.Run()
+
+	deadline := time.After(time.Second)
+	for !subcommand.running() {
+		select {
+		case <-deadline:
+			t.Fatalf("Execution timed out.")
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	if err := subcommand.Signal(syscall.SIGTERM); err != nil {
+		t.Fatalf("Signal returned an error: %v", err)
+	}
+
+	select {
+	case <-subcommand.Done():
+		if code := subcommand.ExitCode(); code != 128+int(syscall.SIGTERM) {
+			t.Errorf("Expected exit code to equal %d. Got %d instead.", 128+int(syscall.SIGTERM), code)
+		}
+	case <-deadline:
+		t.Fatalf("Execution timed out.")
+	}
+
+	if err := subcommand.Signal(syscall.SIGTERM); err != nil {
+		t.Errorf("Expected signalling a finished command to do nothing. Got %v.", err)
+	}
+}
+
+func TestMergeGODEBUG(t *testing.T) {
+	cases := []struct {
+		current  string
+		settings string
+		expected string
+	}{
+		{"", "gctrace=1", "gctrace=1"},
+		{"madvdontneed=1", "gctrace=1", "madvdontneed=1,gctrace=1"},
+		{"gctrace=0,http2debug=1", "gctrace=1,scavtrace=1", "http2debug=1,gctrace=1,scavtrace=1"},
+	}
+
+	for _, c := range cases {
+		if merged := mergeGODEBUG(c.current, c.settings); merged != c.expected {
+			t.Errorf("Expected GODEBUG=%s merged with %s to equal %q. Got %q instead.", c.current, c.settings, c.expected, merged)
+		}
+	}
+}
+
+func TestSubCommandRuntimeEnv(t *testing.T) {
+	t.Setenv("GODEBUG", "madvdontneed=1")
+	t.Setenv("GOGC", "50")
+	t.Setenv("API_TOKEN", "secret")
+
+	subcommand := NewSubCommand([]string{"/usr/bin/env", "true"})
+	env := strings.Join(subcommand.RuntimeEnv(), " ")
+
+	for _, expected := range []string{"GODEBUG=madvdontneed=1,gctrace=1", "GOGC=50"} {
+		if !strings.Contains(env, expected) {
+			t.Errorf("Expected runtime environment to contain %s. Got %q instead.", expected, env)
+		}
+	}
+	if strings.Contains(env, "API_TOKEN") {
+		t.Errorf("Expected runtime environment to leave out other variables. Got %q instead.", env)
+	}
+}
+
+func TestGoExecArgs(t *testing.T) {
+	cases := []struct {
+		args     []string
+		expected []string
+	}{
+		{[]string{"go", "run", "."}, []string{"go", "run", "-exec", "env GODEBUG=gctrace=1", "."}},
+		{[]string{"/usr/local/go/bin/go", "test", "-bench", "."}, []string{"/usr/local/go/bin/go", "test", "-exec", "env GODEBUG=gctrace=1", "-bench", "."}},
+		{[]string{"go", "test", "-exec=sudo", "."}, nil},
+		{[]string{"go", "build", "."}, nil},
+		{[]string{"./server", "run"}, nil},
+	}
+
+	for _, c := range cases {
+		args, ok := goExecArgs(c.args, "env GODEBUG=gctrace=1")
+		if ok != (c.expected != nil) || !reflect.DeepEqual(args, c.expected) {
+			t.Errorf("Expected %q to be rewritten to %q. Got %q instead.", c.args, c.expected, args)
+		}
+	}
+}
+
+func TestSubCommandGoRun(t *testing.T) {
+	t.Setenv("GODEBUG", "madvdontneed=1")
+
+	subcommand := NewSubCommand([]string{"go", "run", "."})
+	env := strings.Join(subcommand.cmd.Env, " ")
+	if !strings.Contains(env, "GODEBUG=madvdontneed=1") || strings.Contains(env, "gctrace") {
+		t.Errorf("Expected the go command to keep its own GODEBUG. Got %q instead.", env)
+	}
+
+	runtimeEnv := strings.Join(subcommand.RuntimeEnv(), " ")
+	if !strings.Contains(runtimeEnv, "GODEBUG=madvdontneed=1,gctrace=1") {
+		t.Errorf("Expected the program's GODEBUG to turn on gctrace. Got %q instead.", runtimeEnv)
+	}
+
+	if args := strings.Join(subcommand.cmd.Args, " "); !strings.Contains(args, execHelperFlag+" madvdontneed=1,gctrace=1") {
+		t.Errorf("Expected go to start the program through gcvis. Got %q instead.", args)
+	}
+}
+
+func TestQuoteExecArg(t *testing.T) {
+	cases := map[string]string{
+		"/usr/local/bin/gcvis":       "/usr/local/bin/gcvis",
+		`C:\Program Files\gcvis.exe`: `'C:\Program Files\gcvis.exe'`,
+		"/home/o'brien/gcvis":        `"/home/o'brien/gcvis"`,
+		"":                           "''",
+	}
+
+	for arg, expected := range cases {
+		if quoted := quoteExecArg(arg); quoted != expected {
+			t.Errorf("Expected %q to be quoted as %s. Got %s instead.", arg, expected, quoted)
+		}
+	}
+}
+
+func TestSubCommandStopAfter(t *testing.T) {
+	cmd := []string{"/usr/bin/env", "sleep", "5"}
+	subcommand := NewSubCommand(cmd)
+	subcommand.StopAfter(50*time.Millisecond, time.Second)
+	go subcommand.Run()
+
+	select {
+	case <-subcommand.Done():
+		if code := subcommand.ExitCode(); code != 0 {
+			t.Errorf("Expected a command stopped after its duration to exit with 0. Got %d instead.", code)
+		}
+		if msg := subcommand.ExitMessage(); msg != "process stopped after 50ms" {
+			t.Errorf("Expected exit message to report the stop. Got %q instead.", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Execution timed out.")
+	}
+}
+
+func TestSubCommandStopProcessGroup(t *testing.T) {
+	// the shell and the sleep it starts both ignore the interrupt, and
+	// sleep holds on to the pipe until it is killed too
+	cmd := []string{"/usr/bin/env", "bash", "-c", "trap '' INT; sleep 5; true"}
+	subcommand := NewSubCommand(cmd)
+	subcommand.StopAfter(50*time.Millisecond, 100*time.Millisecond)
+	go subcommand.Run()
+
+	done := make(chan bool)
+	go func() {
+		ioutil.ReadAll(subcommand.PipeRead)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected every process of the command to be stopped.")
+	}
+}
+
+func TestSubCommandGoTest(t *testing.T) {
+	// a go command that writes a trace to its standard output, as go test
+	// does with those of the test binary
+	dir := t.TempDir()
+	script := "#!/bin/sh\necho 'gc 1 @0.010s 1%: 0.25+1+0.25 ms clock, 0.1+1/1/1+0.1 ms cpu, 4->5->2 MB, 5 MB goal, 8 P'\n"
+	if err := os.WriteFile(filepath.Join(dir, "go"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	subcommand := NewSubCommand([]string{filepath.Join(dir, "go"), "test", "-exec", "env", "."})
+	if !subcommand.capturesStdout() {
+		t.Errorf("Expected the output of go test to be captured.")
+	}
+	if NewSubCommand([]string{"go", "run", "."}).capturesStdout() {
+		t.Errorf("Expected the output of go run to be left alone.")
+	}
+
+	go subcommand.Run()
+	output, err := ioutil.ReadAll(subcommand.PipeRead)
+	if err != nil {
+		t.Fatalf("Reading the output returned an error: %v", err)
+	}
+	if !strings.HasPrefix(string(output), "gc 1 @0.010s") {
+		t.Errorf("Expected the trace on go test's standard output to be read. Got %q instead.", output)
+	}
+}
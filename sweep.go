@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// splitList splits a comma separated flag value.
+func splitList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// sweepSettings returns every combination of the GOGC and GOMEMLIMIT
+// values, each as the environment variables to run with.
+func sweepSettings(gogc, memlimit []string) [][]string {
+	if len(gogc) == 0 {
+		gogc = []string{""}
+	}
+	if len(memlimit) == 0 {
+		memlimit = []string{""}
+	}
+
+	var settings [][]string
+	for _, g := range gogc {
+		for _, m := range memlimit {
+			var setting []string
+			if g != "" {
+				setting = append(setting, "GOGC="+g)
+			}
+			if m != "" {
+				setting = append(setting, "GOMEMLIMIT="+m)
+			}
+			settings = append(settings, setting)
+		}
+	}
+	return settings
+}
+
+// A Sweep runs a command once per setting, one after the other, and
+// collects a session of each run.
+type Sweep struct {
+	Args     []string
+	Settings [][]string
+	GODEBUG  string
+	Duration time.Duration // zero runs each command to completion
+	Mark     *regexp.Regexp
+
+	// Signals, if set, are relayed to the run in progress, and end the
+	// sweep once it has exited.
+	Signals <-chan os.Signal
+}
+
+func (s *Sweep) Run() ([]*Graph, error) {
+	var sessions []*Graph
+	for _, setting := range s.Settings {
+		title := strings.Join(setting, " ")
+		log.Printf("sweep: running %s with %s", strings.Join(s.Args, " "), title)
+
+		graph, interrupted, err := s.run(setting, title)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", title, err)
+		}
+		sessions = append(sessions, graph)
+		if interrupted {
+			log.Printf("sweep: interrupted, leaving out the runs after %s", title)
+			break
+		}
+	}
+	return sessions, nil
+}
+
+func (s *Sweep) run(setting []string, title string) (*Graph, bool, error) {
+	cmd := NewSubCommand(s.Args)
+	cmd.SetGODEBUG(s.GODEBUG)
+	// the terminal is left to gcvis, so that its keys reach the sweep too
+	cmd.SetStdin(nil)
+	for _, kv := range setting {
+		fields := strings.SplitN(kv, "=", 2)
+		cmd.Setenv(fields[0], fields[1])
+	}
+
+	parser := newParser(&readerSource{sc: bufio.NewScanner(cmd.PipeRead), start: time.Now()})
+	parser.Mark = s.Mark
+	if s.Duration > 0 {
+		cmd.StopAfter(s.Duration, stopGrace)
+	}
+	interrupted := make(chan bool, 1)
+	go s.relaySignals(cmd, interrupted)
+	go cmd.Run()
+
+	graph, err := collectSession(title, parser, os.Stderr)
+	if err != nil {
+		return nil, false, err
+	}
+	<-cmd.Done()
+
+	if cmd.ExitCode() != 0 {
+		log.Printf("sweep: %s: %s", title, cmd.ExitMessage())
+	}
+
+	select {
+	case <-interrupted:
+		return graph, true, nil
+	case <-s.Signals:
+		return graph, true, nil
+	default:
+		return graph, false, nil
+	}
+}
+
+// relaySignals sends the signals of the sweep to cmd until it has
+// exited, and reports on interrupted that one came.
+func (s *Sweep) relaySignals(cmd *SubCommand, interrupted chan<- bool) {
+	for {
+		select {
+		case sig := <-s.Signals:
+			if err := cmd.Signal(sig); err != nil {
+				log.Printf("forwarding %v: %v", sig, err)
+			}
+			select {
+			case interrupted <- true:
+			default:
+			}
+		case <-cmd.Done():
+			return
+		}
+	}
+}
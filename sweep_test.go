@@ -0,0 +1,92 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSweepSettings(t *testing.T) {
+	cases := []struct {
+		gogc     []string
+		memlimit []string
+		expected [][]string
+	}{
+		{[]string{"50", "100"}, nil, [][]string{{"GOGC=50"}, {"GOGC=100"}}},
+		{nil, []string{"64MiB"}, [][]string{{"GOMEMLIMIT=64MiB"}}},
+		{[]string{"50", "off"}, []string{"64MiB"}, [][]string{{"GOGC=50", "GOMEMLIMIT=64MiB"}, {"GOGC=off", "GOMEMLIMIT=64MiB"}}},
+	}
+
+	for _, c := range cases {
+		if settings := sweepSettings(c.gogc, c.memlimit); !reflect.DeepEqual(settings, c.expected) {
+			t.Errorf("Expected settings to equal %v. Got %v instead.", c.expected, settings)
+		}
+	}
+}
+
+func TestSweepRun(t *testing.T) {
+	sweep := &Sweep{
+		Args: []string{"/usr/bin/env", "bash", "-c",
+			`echo "gc 1 @0.010s 1%: 0.1+1+0.1 ms clock, 0.1+1/1/1+0.1 ms cpu, 4->5->2 MB, $GOGC MB goal, 4 P" 1>&2; sleep 5`},
+		Settings: [][]string{{"GOGC=50"}, {"GOGC=200"}},
+		GODEBUG:  DefaultGODEBUG,
+		Duration: 100 * time.Millisecond,
+	}
+
+	done := make(chan bool)
+	var sessions []*Graph
+	var err error
+	go func() {
+		sessions, err = sweep.Run()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Execution timed out.")
+	}
+	if err != nil {
+		t.Fatalf("Run returned an error: %v", err)
+	}
+
+	if len(sessions) != 2 || sessions[1].Title != "GOGC=200" {
+		t.Fatalf("Expected a session per setting. Got %v instead.", sessions)
+	}
+	for i, goal := range []int64{50, 200} {
+		if traces := sessions[i].gcTraces; len(traces) != 1 || traces[0].Heap1 != goal {
+			t.Errorf("Expected session %d to have run with GOGC=%d. Got %+v instead.", i, goal, traces)
+		}
+	}
+}
+
+func TestSweepInterrupted(t *testing.T) {
+	signals := make(chan os.Signal, 1)
+	sweep := &Sweep{
+		Args: []string{"/usr/bin/env", "bash", "-c",
+			`echo "gc 1 @0.010s 1%: 0.1+1+0.1 ms clock, 0.1+1/1/1+0.1 ms cpu, 4->5->2 MB, $GOGC MB goal, 4 P" 1>&2; sleep 5`},
+		Settings: [][]string{{"GOGC=50"}, {"GOGC=200"}},
+		GODEBUG:  DefaultGODEBUG,
+		Signals:  signals,
+	}
+
+	done := make(chan bool)
+	var sessions []*Graph
+	go func() {
+		sessions, _ = sweep.Run()
+		close(done)
+	}()
+
+	time.Sleep(200 * time.Millisecond)
+	signals <- os.Interrupt
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Execution timed out.")
+	}
+	if len(sessions) != 1 {
+		t.Errorf("Expected the sweep to end with the interrupted run. Got %d sessions instead.", len(sessions))
+	}
+}
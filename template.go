@@ -5,11 +5,9 @@ const (
 <html>
 <head>
 <title>gcvis - {{ .Title }}</title>
-<script src="//cdnjs.cloudflare.com/ajax/libs/jquery/2.0.3/jquery.min.js"></script>
-<script src="//cdnjs.cloudflare.com/ajax/libs/flot/0.8.2/jquery.flot.min.js"></script>
-<script src="//cdnjs.cloudflare.com/ajax/libs/flot/0.8.2/jquery.flot.selection.min.js"></script>
-<script src="//cdnjs.cloudflare.com/ajax/libs/flot/0.8.2/jquery.flot.stack.min.js"></script>
-
+{{ range .Scripts }}{{ if .Inline }}<script>{{ .Inline }}</script>
+{{ else }}<script src="{{ .Src }}"></script>
+{{ end }}{{ end }}
 <script type="text/javascript">
 
 (function() {
@@ -77,10 +75,34 @@ const (
 		},
 	};
 
+	var rategraph_data = [
+		{ label: "alloc rate", data: {{ .AllocRate }} },
+		{ label: "gc cpu", data: {{ .GCCPUPercent }}, yaxis: 2 },
+	];
+
+	var rategraph_options = {
+		legend: {
+			position: "nw",
+			noColumns: 2,
+			backgroundOpacity: 0.2
+		},
+		yaxes: [
+			{ tickFormatter: function(val) { return val.toFixed(1) + "MB/s"; } },
+			{ position: "right", min: 0, tickFormatter: function(val) { return val + "%"; } }
+		],
+		xaxis: {
+			tickFormatter: function(val) { return val + "s"; }
+		},
+		selection: {
+			mode: "x"
+		},
+	};
+
 	$(document).ready(function() {
 		var datagraph = $.plot("#datagraph", datagraph_data, datagraph_options);
 		var clockgraph = $.plot("#clockgraph", clockgraph_data, timingsgraph_options);
 		var cpugraph = $.plot("#cpugraph", cpugraph_data, timingsgraph_options);
+		var rategraph = $.plot("#rategraph", rategraph_data, rategraph_options);
 
 		var overview = $.plot("#overview", {}, {
 			legend: { show: false},
@@ -106,71 +128,130 @@ const (
 			}
 		});
 
-		// now connect the four
-		$("#datagraph").bind("plotselected", function (event, ranges) {
-
-			// do the zooming
-			$.each(datagraph.getXAxes(), function(_, axis) {
-				var opts = axis.options;
-				opts.min = ranges.xaxis.from;
-				opts.max = ranges.xaxis.to;
+		// now connect the graphs to each other and to the overview
+		var graphs = [datagraph, clockgraph, cpugraph, rategraph];
+
+		$.each(graphs, function(_, graph) {
+			graph.getPlaceholder().bind("plotselected", function (event, ranges) {
+
+				// do the zooming
+				$.each(graph.getXAxes(), function(_, axis) {
+					var opts = axis.options;
+					opts.min = ranges.xaxis.from;
+					opts.max = ranges.xaxis.to;
+				});
+				graph.setupGrid();
+				graph.draw();
+				graph.clearSelection();
+
+				// don't fire event on the overview to prevent eternal loop
+				overview.setSelection(ranges, true);
+				$.each(graphs, function(_, other) {
+					if (other !== graph) {
+						other.setSelection(ranges, true);
+					}
+				});
 			});
-			datagraph.setupGrid();
-			datagraph.draw();
-			datagraph.clearSelection();
-
-			// don't fire event on the overview to prevent eternal loop
-			overview.setSelection(ranges, true);
-			clockgraph.setSelection(ranges, true);
-			cpugraph.setSelection(ranges, true);
 		});
 
-		$("#clockgraph").bind("plotselected", function (event, ranges) {
+		// shade the warm-up, and draw alert breaches and annotations as vertical lines across every graph
+		function setMarkings(graphData) {
+			var markings = [];
+			if (graphData.WarmupEnd > 0) {
+				markings.push({ xaxis: { to: graphData.WarmupEnd }, color: "#f0f0f0" });
+			}
+			$.each(graphData.Breaches || [], function(_, breach) {
+				markings.push({ xaxis: { from: breach.ElapsedTime, to: breach.ElapsedTime }, color: "#d00", lineWidth: 1 });
+			});
+			$.each(graphData.Annotations || [], function(_, annotation) {
+				markings.push({ xaxis: { from: annotation.ElapsedTime, to: annotation.ElapsedTime }, color: "#06c", lineWidth: 1 });
+			});
+			$.each(graphs, function(_, graph) {
+				graph.getOptions().grid.markings = markings;
+				graph.draw();
+			});
 
-			// do the zooming
-			$.each(clockgraph.getXAxes(), function(_, axis) {
-				var opts = axis.options;
-				opts.min = ranges.xaxis.from;
-				opts.max = ranges.xaxis.to;
+			$("#breaches").empty();
+			$.each(graphData.Breaches || [], function(_, breach) {
+				$("#breaches").append($("<li>").text(breach.ElapsedTime.toFixed(3) + "s: " + breach.Rule + " (" + breach.Value + ")"));
 			});
-			clockgraph.setupGrid();
-			clockgraph.draw();
-			clockgraph.clearSelection();
 
-			// don't fire event on the overview to prevent eternal loop
+			$("#annotations").empty();
+			$.each(graphData.Annotations || [], function(_, annotation) {
+				$("#annotations").append($("<li>").text(annotation.ElapsedTime.toFixed(3) + "s: " + annotation.Label));
+			});
+		}
 
-			overview.setSelection(ranges, true);
-			datagraph.setSelection(ranges, true);
-			cpugraph.setSelection(ranges, true);
+		$("#overview").bind("plotselected", function (event, ranges) {
+			$.each(graphs, function(_, graph) {
+				graph.setSelection(ranges);
+			});
 		});
 
-		$("#cpugraph").bind("plotselected", function (event, ranges) {
+		function showEnded(ended) {
+			if (ended) {
+				$("#ended-message").text(ended);
+				$("#ended").show();
+			}
+		}
 
-			// do the zooming
-			$.each(cpugraph.getXAxes(), function(_, axis) {
-				var opts = axis.options;
-				opts.min = ranges.xaxis.from;
-				opts.max = ranges.xaxis.to;
+		$("#quit").click(function() {
+			$.post(window.location.href + 'quit', function() {
+				$("#quit").remove();
+				$("#ended-message").append(", gcvis has exited");
 			});
-			cpugraph.setupGrid();
-			cpugraph.draw();
-			cpugraph.clearSelection();
+		});
+
+		if ({{ .Static }}) {
+			showEnded({{ .Ended }});
+			showSummary({{ .Summary }});
+			showBenchmarks({{ .Benchmarks }});
+			showRuns({{ .Runs }});
+			setMarkings({ Breaches: {{ .Breaches }}, Annotations: {{ .Annotations }}, WarmupEnd: {{ .WarmupEnd }} });
+
+			overview.setData(datagraph_data);
+			overview.setupGrid();
+			overview.draw();
+		} else {
+			// refresh data every second
+			pullAndRedraw();
+			pullReplayStatus();
+		}
 
-			// don't fire event on the overview to prevent eternal loop
+		function replayControl(params) {
+			$.post(window.location.href + 'replay', params, showReplayStatus);
+		}
+
+		function showReplayStatus(status) {
+			$("#replay").show();
+			$("#replay-position").attr("max", status.Duration);
+			if (!$("#replay-position").is(":active")) {
+				$("#replay-position").val(status.Position);
+			}
+			$("#replay-time").text(status.Position.toFixed(1) + "s / " + status.Duration.toFixed(1) + "s");
+			$("#replay-toggle").text(status.Paused ? "play" : "pause");
+			$("#replay-toggle").data("action", status.Paused ? "play" : "pause");
+			$("#replay-speed").val(status.Speed);
+		}
 
-			overview.setSelection(ranges, true);
-			datagraph.setSelection(ranges, true);
-			clockraph.setSelection(ranges, true);
+		function pullReplayStatus() {
+			$.get(window.location.href + 'replay', function(status) {
+				showReplayStatus(status);
+				setTimeout(pullReplayStatus, 1000);
+			});
+		}
+
+		$("#replay-toggle").click(function() {
+			replayControl({ action: $(this).data("action") });
 		});
 
-		$("#overview").bind("plotselected", function (event, ranges) {
-			datagraph.setSelection(ranges);
-			clockgraph.setSelection(ranges);
-			cpugraph.setSelection(ranges);
+		$("#replay-position").change(function() {
+			replayControl({ action: "seek", position: $(this).val() });
 		});
 
-		// refresh data every second
-		pullAndRedraw();
+		$("#replay-speed").change(function() {
+			replayControl({ action: "speed", speed: $(this).val() });
+		});
 
 		function pullAndRedraw() {
 			$.get(window.location.href + 'graph.json', function(graphData) {
@@ -194,6 +275,10 @@ const (
 					{ label: "con mas idle cpu",   data: graphData.MASIdlecpu },
 					{ label: "STW mark cpu",       data: graphData.STWMcpu },
 				];
+				var rategraph_data = [
+					{ label: "alloc rate",         data: graphData.AllocRate },
+					{ label: "gc cpu",             data: graphData.GCCPUPercent, yaxis: 2 },
+				];
 
 				datagraph.setData(datagraph_data);
 				datagraph.setupGrid();
@@ -207,12 +292,96 @@ const (
 				cpugraph.setupGrid();
 				cpugraph.draw();
 
+				rategraph.setData(rategraph_data);
+				rategraph.setupGrid();
+				rategraph.draw();
+
+				setMarkings(graphData);
+				showEnded(graphData.Ended);
+
 				overview.setData(datagraph_data);
 				overview.setupGrid();
 				overview.draw();
 
 				setTimeout(pullAndRedraw, 1000);
 			})
+
+			$.get(window.location.href + 'summary.json', showSummary);
+			$.get(window.location.href + 'benchmarks.json', showBenchmarks);
+			$.get(window.location.href + 'runs.json', showRuns);
+		}
+
+		// fill table with one row of cycle statistics per entry of groups,
+		// led by the cells lead returns; click, if set, is bound to each row
+		function showCycleStats(table, groups, lead, click) {
+			var body = $("<tbody>");
+			$.each(groups, function(_, g) {
+				var row = $("<tr>");
+				$.each(lead(g), function(i, cell) {
+					row.append((i == 0 ? $("<th>") : $("<td>")).text(cell));
+				});
+				if (g.Cycles !== undefined) {
+					row.append($("<td>").text(g.Cycles))
+						.append($("<td>").text(g.PauseTotal.toFixed(3) + "ms"))
+						.append($("<td>").text(g.PeakGoal + "MB"))
+						.append($("<td>").text(g.PeakLive + "MB"));
+				}
+				if (click) {
+					row.click(function() { click(g); });
+				}
+				body.append(row);
+			});
+			$(table + " tbody").replaceWith(body);
+			$(table).show();
+		}
+
+		// list the runs of a restarted program; clicking one zooms every graph to it
+		function showRuns(runs) {
+			if (!runs || runs.length == 0) {
+				return;
+			}
+
+			var all = { Run: "all runs", Start: runs[0].Start, End: runs[runs.length - 1].End };
+			showCycleStats("#runs", runs.concat([all]), function(r) {
+				if (r === all) {
+					return [r.Run];
+				}
+				return ["run " + r.Run, r.Start.toFixed(3) + "s", r.End.toFixed(3) + "s"];
+			}, function(r) {
+				overview.setSelection({ xaxis: { from: r.Start, to: r.End } });
+			});
+		}
+
+		function showBenchmarks(benchmarks) {
+			if (!benchmarks || benchmarks.length == 0) {
+				return;
+			}
+
+			showCycleStats("#benchmarks", benchmarks, function(b) { return [b.Name]; });
+		}
+
+		function showSummary(summary) {
+			var rows = [
+				["cycles",            function(s) { return s.Cycles; }],
+				["pause p50",         function(s) { return s.PauseP50.toFixed(3) + "ms"; }],
+				["pause p95",         function(s) { return s.PauseP95.toFixed(3) + "ms"; }],
+				["pause p99",         function(s) { return s.PauseP99.toFixed(3) + "ms"; }],
+				["pause max",         function(s) { return s.PauseMax.toFixed(3) + "ms"; }],
+				["GCs per minute",    function(s) { return s.GCPerMinute.toFixed(1); }],
+				["mean interval",     function(s) { return s.MeanInterval.toFixed(3) + "s"; }],
+				["GC cpu / wall time", function(s) { return (s.GCCPU / 1000).toFixed(2) + "s / " + s.WallTime.toFixed(1) + "s (" + (s.GCCPUFraction * 100).toFixed(2) + "%)"; }],
+				["assist share",      function(s) { return (s.AssistShare * 100).toFixed(1) + "%"; }]
+			];
+
+			var body = $("<tbody>");
+			$.each(rows, function(_, row) {
+				body.append($("<tr>")
+					.append($("<th>").text(row[0]))
+					.append($("<td>").text(row[1](summary.Session)))
+					.append($("<td>").text(row[1](summary.Rolling))));
+			});
+			$("#summary-window").text("last " + summary.Window + "s");
+			$("#summary tbody").replaceWith(body);
 		}
 	});
 })();
@@ -223,12 +392,74 @@ const (
 	padding: 10px;
 }
 
+#env {
+	color: #666;
+}
+
 #export {
 	float: right;
 }
+
+#replay {
+	display: none;
+	width: 1200px;
+	margin: 0 auto;
+}
+
+#replay-position {
+	width: 800px;
+	vertical-align: middle;
+}
 dt { float: left; font-weight:bold; width: 160px; }
 dd { margin-left: 160px; }
 
+#ended {
+	display: none;
+	width: 1200px;
+	margin: 10px auto;
+	padding: 5px 10px;
+	box-sizing: border-box;
+	background: #fff3cd;
+	border: 1px solid #e0c060;
+}
+
+#breaches {
+	width: 1200px;
+	margin: 0 auto;
+	color: #d00;
+}
+
+#annotations {
+	width: 1200px;
+	margin: 0 auto;
+	color: #06c;
+}
+
+#summary {
+	width: 1200px;
+	margin: 15px auto;
+	border-collapse: collapse;
+	font-size: 14px;
+}
+
+#benchmarks, #runs {
+	display: none;
+	width: 1200px;
+	margin: 15px auto;
+	border-collapse: collapse;
+	font-size: 14px;
+}
+
+#summary th, #summary td, #benchmarks th, #benchmarks td, #runs th, #runs td {
+	text-align: left;
+	padding: 2px 10px;
+	border-bottom: 1px solid #eee;
+}
+
+#runs tbody tr {
+	cursor: pointer;
+}
+
 .graph-container {
 	box-sizing: border-box;
 	width: 1200px;
@@ -300,11 +531,55 @@ dd { margin-left: 160px; }
 </head>
 <body>
 <pre>{{ .Title }}</pre>
-<div id="export">
+{{ if .Env }}<pre id="env">{{ range .Env }}{{ . }}  {{ end }}</pre>{{ end }}
+{{ if not .Static }}<div id="export">
 	<a href="/graph.json">json</a>
+	<a href="/export.csv">csv</a>
+	<a href="/export.ndjson">ndjson</a>
+	<a href="/series.csv">series csv</a>
+	<a href="/summary.json">summary</a>
+	<a href="/report.html">html report</a>
+	<a href="/chart/heap.svg">heap svg</a>
+	<a href="/chart/clock.svg">clock svg</a>
+	<a href="/chart/cpu.svg">cpu svg</a>
+</div>{{ end }}
+<div id="replay">
+	<button id="replay-toggle">play</button>
+	<input id="replay-position" type="range" min="0" step="0.1" value="0" />
+	<span id="replay-time"></span>
+	<select id="replay-speed">
+		<option value="0.5">0.5x</option>
+		<option value="1">1x</option>
+		<option value="2">2x</option>
+		<option value="5">5x</option>
+		<option value="10">10x</option>
+		<option value="100">100x</option>
+	</select>
+</div>
+<div id="ended">
+	<span id="ended-message"></span>
+	{{ if not .Static }}<button id="quit">quit gcvis</button>{{ end }}
 </div>
 <div id="content">
 
+	<ul id="breaches"></ul>
+	<ul id="annotations"></ul>
+
+	<table id="summary">
+		<thead><tr><th></th><th>session</th><th id="summary-window">rolling</th></tr></thead>
+		<tbody></tbody>
+	</table>
+
+	<table id="benchmarks">
+		<thead><tr><th>benchmark</th><th>cycles</th><th>pause total</th><th>peak goal</th><th>peak live</th></tr></thead>
+		<tbody></tbody>
+	</table>
+
+	<table id="runs">
+		<thead><tr><th>run</th><th>from</th><th>to</th><th>cycles</th><th>pause total</th><th>peak goal</th><th>peak live</th></tr></thead>
+		<tbody></tbody>
+	</table>
+
 	<div class="graph-container">
 		<div id="datagraph" class="demo-placeholder"></div>
 	</div>
@@ -317,6 +592,10 @@ dd { margin-left: 160px; }
 		<div id="cpugraph" class="demo-placeholder"></div>
 	</div>
 
+	<div class="small-graph-container">
+		<div id="rategraph" class="demo-placeholder"></div>
+	</div>
+
 	<div class="legend-container" style="height:60px;">
 		<div id="overview" class="demo-placeholder"></div>
 	</div>
@@ -343,10 +622,282 @@ dd { margin-left: 160px; }
 <dt>con mas bg cpu    </dt><dd>concurrent mark and scan - background GC cpu time</dd>
 <dt>con mas idle cpu  </dt><dd>concurrent mark and scan - idle GC cpu time</dd>
 <dt>STW mark cpu      </dt><dd>stop-the-world mark cpu time</dd>
+
+<dt>alloc rate        </dt><dd>heap allocated between the end of one gc and the start of the next, per second</dd>
+<dt>gc cpu            </dt><dd>share of cpu spent in gc since the program started</dd>
 </dl>
 
 </pre>
 </body>
+</html>
+	`
+
+	COMPARE_TMPL = `
+<html>
+<head>
+<title>gcvis - {{ .Title }}</title>
+{{ range .Scripts }}{{ if .Inline }}<script>{{ .Inline }}</script>
+{{ else }}<script src="{{ .Src }}"></script>
+{{ end }}{{ end }}
+<script type="text/javascript">
+
+(function() {
+	var sessions = {{ .Sessions }};
+	var stats = {{ .Stats }};
+	var colors = ["#4a7ebb", "#d9822b", "#5a9e4b", "#b8453f", "#8064a2", "#4bacc6", "#9b7b3a", "#7f7f7f"];
+
+	function options(unit) {
+		// shade the warm-up of every session, which is left out of its statistics
+		var markings = [];
+		$.each(sessions, function(_, session) {
+			if (session.WarmupEnd > 0) {
+				markings.push({ xaxis: { to: session.WarmupEnd }, color: "#f0f0f0" });
+			}
+		});
+
+		return {
+			legend: {
+				position: "nw",
+				noColumns: 2,
+				backgroundOpacity: 0.2
+			},
+			grid: {
+				markings: markings
+			},
+			yaxis: {
+				tickFormatter: function(val) { return val + unit; }
+			},
+			xaxis: {
+				tickFormatter: function(val) { return val + "s"; }
+			}
+		};
+	}
+
+	// draw the given statistics of every session as groups of bars
+	function plotStats(placeholder, keys) {
+		var width = 0.8 / keys.length;
+		var data = [];
+		$.each(keys, function(k, key) {
+			$.each(stats, function(_, row) {
+				if (row.Key != key) {
+					return;
+				}
+				data.push({
+					label: keys.length > 1 ? row.Name : null,
+					data: $.map(row.Values, function(v, i) { return [[i - 0.4 + k * width, v]]; }),
+					bars: { show: true, barWidth: width, fill: 0.7 }
+				});
+			});
+		});
+
+		$.plot(placeholder, data, {
+			legend: { position: "nw", backgroundOpacity: 0.2 },
+			xaxis: {
+				min: -0.5,
+				max: sessions.length - 0.5,
+				ticks: $.map(sessions, function(session, i) { return [[i, session.Title]]; })
+			},
+			yaxis: { min: 0 }
+		});
+	}
+
+	function draw() {
+		var heapgraph_data = [];
+		var pausegraph_data = [];
+		$.each(sessions, function(i, session) {
+			var color = colors[i % colors.length];
+			heapgraph_data.push({ label: session.Title + " goal", data: session.Goal, color: color, lines: { lineWidth: 2 } });
+			heapgraph_data.push({ label: session.Title + " live", data: session.Live, color: color, lines: { lineWidth: 1 } });
+			pausegraph_data.push({ label: session.Title, data: session.Pause, color: color });
+		});
+
+		$.plot("#heapgraph", heapgraph_data, options("MB"));
+		$.plot("#pausegraph", pausegraph_data, options("ms"));
+		plotStats("#rategraph", ["rate"]);
+		plotStats("#goalgraph", ["goal"]);
+		plotStats("#percentilegraph", ["p50", "p95", "p99"]);
+		plotStats("#cpugraph", ["cpu"]);
+		showSessions();
+	}
+
+	// list the sessions in their colours, with how each one ended
+	function showSessions() {
+		$("#sessions").empty();
+		$.each(sessions, function(i, session) {
+			var item = $("<li>")
+				.append($("<span>").addClass("swatch").css("background", colors[i % colors.length]))
+				.append($("<span>").text(session.Title));
+			if (session.Ended) {
+				item.append($("<span>").addClass("ended").text(session.Ended));
+			}
+			$("#sessions").append(item);
+		});
+	}
+
+	function change(before, after) {
+		if (before == 0) {
+			return "n/a";
+		}
+		var c = (after - before) / before * 100;
+		return (c < 0 ? "" : "+") + c.toFixed(1) + "%";
+	}
+
+	function showStats() {
+		var body = $("<tbody>");
+		$.each(stats, function(_, row) {
+			var tr = $("<tr>").append($("<th>").text(row.Name));
+			$.each(row.Values, function(i, v) {
+				tr.append($("<td>").text(Number(v.toPrecision(4)) + row.Unit));
+				if (i > 0) {
+					tr.append($("<td>").text(change(row.Values[0], v)));
+				}
+			});
+			body.append(tr);
+		});
+		$("#stats tbody").replaceWith(body);
+	}
+
+	function pullAndRedraw() {
+		$.get(window.location.href + 'comparison.json', function(comparison) {
+			sessions = comparison.Sessions;
+			stats = comparison.Stats;
+			draw();
+			showStats();
+
+			// keep pulling until every session has ended
+			var running = $.grep(sessions, function(session) { return !session.Ended; });
+			if (running.length > 0) {
+				setTimeout(pullAndRedraw, 1000);
+			}
+		});
+	}
+
+	$(document).ready(function() {
+		draw();
+		if ({{ .Live }}) {
+			setTimeout(pullAndRedraw, 1000);
+		}
+	});
+})();
+</script>
+<style>
+#content {
+	margin: 0 auto;
+	padding: 10px;
+}
+
+#stats {
+	width: 1200px;
+	margin: 15px auto;
+	border-collapse: collapse;
+	font-size: 14px;
+}
+
+#stats th, #stats td {
+	text-align: left;
+	padding: 2px 10px;
+	border-bottom: 1px solid #eee;
+}
+
+#sessions {
+	width: 1200px;
+	margin: 0 auto;
+	padding: 0;
+	list-style: none;
+	font-size: 14px;
+}
+
+#sessions .swatch {
+	display: inline-block;
+	width: 12px;
+	height: 12px;
+	margin-right: 8px;
+}
+
+#sessions .ended {
+	margin-left: 8px;
+	color: #888;
+}
+
+.graph-container {
+	box-sizing: border-box;
+	width: 1200px;
+	height: 340px;
+	padding: 20px 15px 15px 15px;
+	margin: 15px auto 30px auto;
+	border: 1px solid #ddd;
+	background: #fff;
+	background: linear-gradient(#f6f6f6 0, #fff 50px);
+	box-shadow: 0 3px 10px rgba(0,0,0,0.15);
+}
+
+.stats-row {
+	width: 1200px;
+	margin: 0 auto;
+}
+
+.stats-graph-container {
+	box-sizing: border-box;
+	display: inline-block;
+	width: 292px;
+	height: 240px;
+	padding: 20px 10px 10px 10px;
+	margin: 0 0 30px 0;
+	border: 1px solid #ddd;
+	background: #fff;
+	box-shadow: 0 3px 10px rgba(0,0,0,0.15);
+}
+
+.stats-graph-container h4 {
+	margin: -15px 0 5px 0;
+	font-size: 12px;
+}
+
+.demo-placeholder {
+	width: 100%;
+	height: 100%;
+	font-size: 14px;
+	line-height: 1.2em;
+}
+
+.stats-graph-container .demo-placeholder {
+	height: 90%;
+}
+</style>
+</head>
+<body>
+<pre>{{ .Title }}</pre>
+<div id="content">
+
+	<ul id="sessions"></ul>
+
+	<table id="stats">
+		<thead><tr><th></th>{{ range $i, $s := .Sessions }}<th>{{ $s.Title }}</th>{{ if $i }}<th>change</th>{{ end }}{{ end }}</tr></thead>
+		<tbody>
+		{{ range $row := .Stats }}<tr>
+			<th>{{ $row.Name }}</th>
+			{{ range $i, $v := $row.Values }}<td>{{ printf "%.4g" $v }}{{ $row.Unit }}</td>{{ if $i }}<td>{{ ($row.Delta $i).Change }}</td>{{ end }}{{ end }}
+		</tr>
+		{{ end }}</tbody>
+	</table>
+
+	<div class="stats-row">
+		<div class="stats-graph-container"><h4>GCs per minute</h4><div id="rategraph" class="demo-placeholder"></div></div>
+		<div class="stats-graph-container"><h4>peak heap goal (MB)</h4><div id="goalgraph" class="demo-placeholder"></div></div>
+		<div class="stats-graph-container"><h4>pause percentiles (ms)</h4><div id="percentilegraph" class="demo-placeholder"></div></div>
+		<div class="stats-graph-container"><h4>GC cpu (%)</h4><div id="cpugraph" class="demo-placeholder"></div></div>
+	</div>
+
+	<div class="graph-container">
+		<div id="heapgraph" class="demo-placeholder"></div>
+	</div>
+
+	<div class="graph-container">
+		<div id="pausegraph" class="demo-placeholder"></div>
+	</div>
+
+</div>
+</body>
 </html>
 	`
 )
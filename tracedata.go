@@ -11,6 +11,9 @@ type scvgtrace struct {
 
 type gctrace struct {
 	ElapsedTime  float64 // in seconds
+	CPUPercent   int64   // share of CPU spent in GC since the program started
+	Benchmark    string  // benchmark running during the cycle, if any
+	Run          int     // run of the program, counting restarts from 1
 	NumGC        int64
 	Nproc        int64
 	t1           int64
@@ -19,6 +22,7 @@ type gctrace struct {
 	t4           int64
 	Heap0        int64 // heap size before, in megabytes
 	Heap1        int64 // heap size after, in megabytes
+	HeapLive     int64 // live heap after marking, in megabytes
 	Obj          int64
 	NMalloc      int64
 	NFree        int64
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+const (
+	tuiClear       = "\x1b[H\x1b[2J"
+	tuiOutputLines = 500
+)
+
+var sparkTicks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws the last width values as a line of block characters,
+// scaled between their minimum and maximum.
+func sparkline(values []float64, width int) string {
+	if len(values) > width {
+		values = values[len(values)-width:]
+	}
+	if len(values) == 0 {
+		return ""
+	}
+
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		min = math.Min(min, v)
+		max = math.Max(max, v)
+	}
+
+	line := make([]rune, len(values))
+	for i, v := range values {
+		tick := 0
+		if max > min {
+			tick = int((v - min) / (max - min) * float64(len(sparkTicks)-1))
+		}
+		line[i] = sparkTicks[tick]
+	}
+	return string(line)
+}
+
+// A TUI draws a live dashboard of a Graph on a terminal, along with the
+// output of the program being traced.
+type TUI struct {
+	graph  *Graph
+	output []string
+}
+
+func NewTUI(graph *Graph) *TUI {
+	return &TUI{graph: graph}
+}
+
+func (t *TUI) AddOutput(line string) {
+	t.output = append(t.output, line)
+	if len(t.output) > tuiOutputLines {
+		t.output = t.output[len(t.output)-tuiOutputLines:]
+	}
+}
+
+// Draw clears the terminal and draws the dashboard to fit in width
+// columns and height rows.
+func (t *TUI) Draw(w io.Writer, width, height int) error {
+	traces := t.graph.recentTraces(width)
+	summary := t.graph.Summary()
+
+	bw := bufio.NewWriter(w)
+	rows := 0
+	line := func(format string, args ...interface{}) {
+		s := fmt.Sprintf(format, args...)
+		if r := []rune(s); len(r) > width {
+			s = string(r[:width])
+		}
+		bw.WriteString(s + "\r\n")
+		rows++
+	}
+
+	bw.WriteString(tuiClear)
+	line("gcvis - %s", t.graph.Title)
+	line("")
+
+	var goal, live, pause []float64
+	for _, trace := range traces {
+		goal = append(goal, float64(trace.Heap1))
+		live = append(live, float64(trace.HeapLive))
+		pause = append(pause, pauseMs(trace))
+	}
+	sparkWidth := width - 28
+	if sparkWidth < 1 {
+		sparkWidth = 1
+	}
+	if n := len(traces); n > 0 {
+		last := traces[n-1]
+		line("heap goal  %8d MB  %s", last.Heap1, sparkline(goal, sparkWidth))
+		line("live heap  %8d MB  %s", last.HeapLive, sparkline(live, sparkWidth))
+		line("pause    %10.3f ms  %s", pauseMs(last), sparkline(pause, sparkWidth))
+		line("")
+		line("latest cycle @%.3fs, %d P, %d%% cpu in gc since start", last.ElapsedTime, last.Nproc, last.CPUPercent)
+		line("  clock  STW sweep %.3f  mark/scan %.3f  STW mark %.3f ms", last.STWSclock, last.MASclock, last.STWMclock)
+		line("  cpu    STW sweep %.3f  assist %.3f  bg %.3f  idle %.3f  STW mark %.3f ms",
+			last.STWScpu, last.MASAssistcpu, last.MASBGcpu, last.MASIdlecpu, last.STWMcpu)
+		line("  heap   %d -> live %d MB, goal %d MB", last.Heap0, last.HeapLive, last.Heap1)
+	} else {
+		line("waiting for the first GC cycle")
+	}
+	line("")
+
+	r := summary.Rolling
+	line("last %.0fs: %d cycles  pause p50 %.3f  p95 %.3f  p99 %.3f  max %.3f ms  %.1f GCs/min  gc cpu %.2f%%",
+		summary.Window, r.Cycles, r.PauseP50, r.PauseP95, r.PauseP99, r.PauseMax, r.GCPerMinute, r.GCCPUFraction*100)
+	line("%s", strings.Repeat("─", width))
+
+	output := t.output
+	if room := height - rows - 1; len(output) > room {
+		if room < 0 {
+			room = 0
+		}
+		output = output[len(output)-room:]
+	}
+	for _, o := range output {
+		line("%s", o)
+	}
+
+	return bw.Flush()
+}
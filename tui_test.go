@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSparkline(t *testing.T) {
+	if line := sparkline([]float64{0, 7, 14}, 10); line != "▁▄█" {
+		t.Errorf("Expected sparkline to equal '▁▄█'. Got '%v'.", line)
+	}
+
+	if line := sparkline([]float64{5, 1, 2, 3}, 3); line != "▁▄█" {
+		t.Errorf("Expected sparkline to keep the last 3 values. Got '%v'.", line)
+	}
+
+	if line := sparkline([]float64{3, 3}, 10); line != "▁▁" {
+		t.Errorf("Expected a flat sparkline. Got '%v'.", line)
+	}
+}
+
+func TestTUIDraw(t *testing.T) {
+	graph := NewGraph("fake title", GCVIS_TMPL)
+	graph.AddGCTraceGraphPoint(&gctrace{ElapsedTime: 1, Heap1: 8, HeapLive: 3, STWSclock: 0.5, Nproc: 4})
+	tui := NewTUI(&graph)
+	for i := 0; i < 100; i++ {
+		tui.AddOutput("child output")
+	}
+	tui.AddOutput("last line")
+
+	w := &bytes.Buffer{}
+	if err := tui.Draw(w, 120, 30); err != nil {
+		t.Fatalf("Draw returned an error: %v", err)
+	}
+
+	out := w.String()
+	for _, expected := range []string{"gcvis - fake title", "heap goal         8 MB", "latest cycle @1.000s, 4 P", "last line"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Expected dashboard to contain %q. Got:\n%v", expected, out)
+		}
+	}
+	if rows := strings.Count(out, "\r\n"); rows >= 30 {
+		t.Errorf("Expected dashboard to fit in 30 rows. Got %d.", rows)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// A Warmup is the start of a session that is left out of statistics and
+// alerts while the program settles: a number of seconds, or of GC
+// cycles.
+type Warmup struct {
+	Seconds float64
+	Cycles  int
+}
+
+// ParseWarmup parses a warm-up written as a duration, such as 30s, or as
+// a number of GC cycles, such as 100.
+func ParseWarmup(s string) (Warmup, error) {
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 {
+		return Warmup{Cycles: n}, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return Warmup{}, fmt.Errorf("warm-up %q: expected a duration or a number of GC cycles", s)
+	}
+	return Warmup{Seconds: d.Seconds()}, nil
+}
+
+func (w Warmup) String() string {
+	if w.Cycles > 0 {
+		return strconv.Itoa(w.Cycles)
+	}
+	return time.Duration(w.Seconds * float64(time.Second)).String()
+}
+
+func (w *Warmup) Set(s string) error {
+	var err error
+	*w, err = ParseWarmup(s)
+	return err
+}
+
+// Over reports whether the nth GC cycle of a session, counting from 1,
+// which took place at elapsed seconds, comes after the warm-up.
+func (w Warmup) Over(n int, elapsed float64) bool {
+	return n > w.Cycles && elapsed >= w.Seconds
+}
+
+// split returns how many of traces fall within the warm-up, and when it
+// ends. A warm-up of cycles that is not over yet lasts until the last of
+// traces.
+func (w Warmup) split(traces []*gctrace) (int, float64) {
+	end := w.Seconds
+	for i, t := range traces {
+		if w.Over(i+1, t.ElapsedTime) {
+			return i, end
+		}
+		if w.Cycles > 0 {
+			end = math.Max(end, t.ElapsedTime)
+		}
+	}
+	return len(traces), end
+}
+
+// SetWarmup sets the start of the session to leave out of its
+// statistics.
+func (g *Graph) SetWarmup(w Warmup) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.warmup = w
+	_, g.WarmupEnd = w.split(g.gcTraces)
+}
+
+// measured returns the traces that come after the warm-up, and when the
+// warm-up ends.
+func (g *Graph) measured() ([]*gctrace, float64) {
+	n, end := g.warmup.split(g.gcTraces)
+	return g.gcTraces[n:], end
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParseWarmup(t *testing.T) {
+	cases := []struct {
+		s        string
+		expected Warmup
+	}{
+		{"30s", Warmup{Seconds: 30}},
+		{"1m30s", Warmup{Seconds: 90}},
+		{"100", Warmup{Cycles: 100}},
+	}
+
+	for _, c := range cases {
+		w, err := ParseWarmup(c.s)
+		if err != nil {
+			t.Fatalf("ParseWarmup(%q) returned an error: %v", c.s, err)
+		}
+		if w != c.expected {
+			t.Errorf("Expected warm-up %q to equal %+v. Got %+v instead.", c.s, c.expected, w)
+		}
+	}
+
+	for _, s := range []string{"", "-5s", "ten"} {
+		if _, err := ParseWarmup(s); err == nil {
+			t.Errorf("Expected ParseWarmup(%q) to fail.", s)
+		}
+	}
+}
+
+func TestGraphWarmup(t *testing.T) {
+	graph := NewGraph("warmup", GCVIS_TMPL)
+	graph.SetWarmup(Warmup{Cycles: 2})
+	for i, pause := range []float64{50, 40, 1, 2} {
+		graph.AddGCTraceGraphPoint(&gctrace{ElapsedTime: float64(i + 1), Heap1: int64(100 - i), STWSclock: pause, Nproc: 1})
+	}
+
+	if graph.WarmupEnd != 2 {
+		t.Errorf("Expected warm-up to end at 2s. Got %v instead.", graph.WarmupEnd)
+	}
+
+	r := graph.Report()
+	if r.Summary.Cycles != 2 || r.WarmupCycles != 2 {
+		t.Errorf("Expected 2 cycles measured after 2 cycles of warm-up. Got %d after %d.", r.Summary.Cycles, r.WarmupCycles)
+	}
+	if r.Summary.PauseMax != 2 || r.PeakGoal != 98 {
+		t.Errorf("Expected the warm-up to be left out of statistics. Got pause max %v and peak goal %v.", r.Summary.PauseMax, r.PeakGoal)
+	}
+	if r.Summary.WallTime != 2 {
+		t.Errorf("Expected wall time to be measured from the end of the warm-up. Got %v instead.", r.Summary.WallTime)
+	}
+}
+
+func TestAlerterWarmup(t *testing.T) {
+	rule, _ := ParseAlertRule("pause>10")
+	alerter := NewAlerter([]AlertRule{rule})
+	alerter.Warmup = Warmup{Seconds: 5}
+
+	if breaches := alerter.Check(&gctrace{ElapsedTime: 1, STWSclock: 20}); len(breaches) != 0 {
+		t.Errorf("Expected no breach during the warm-up. Got %v.", breaches)
+	}
+	if breaches := alerter.Check(&gctrace{ElapsedTime: 6, STWSclock: 20}); len(breaches) != 1 {
+		t.Errorf("Expected a breach after the warm-up. Got %v.", breaches)
+	}
+}